@@ -0,0 +1,55 @@
+package inbound
+
+import (
+	"net"
+
+	C "github.com/Dreamacro/clash/constant"
+)
+
+// Addition mutates a freshly parsed Metadata, letting NewHTTP/NewSocket/
+// NewPacket accept inbound-specific metadata without every constructor
+// growing its own parameter for each new field.
+type Addition func(metadata *C.Metadata)
+
+func applyAdditions(metadata *C.Metadata, additions ...Addition) *C.Metadata {
+	for _, addition := range additions {
+		addition(metadata)
+	}
+	return metadata
+}
+
+// WithInName tags metadata with the name of the inbound listener instance it
+// arrived on, so rules can match PREFER-RULES / IN-NAME against it.
+func WithInName(name string) Addition {
+	return func(metadata *C.Metadata) {
+		metadata.InName = name
+	}
+}
+
+// WithPreferRulesName points metadata at a named sub-ruleset to evaluate
+// first, letting a specific inbound shortcut straight into it.
+func WithPreferRulesName(name string) Addition {
+	return func(metadata *C.Metadata) {
+		metadata.PreferRulesName = name
+	}
+}
+
+// WithSrcAddr fills in metadata's source address fields from addr, mirroring
+// what parseSocksAddr/parseHTTPAddr do for the destination.
+func WithSrcAddr(addr net.Addr) Addition {
+	return func(metadata *C.Metadata) {
+		if ip, port, err := parseAddr(addr.String()); err == nil {
+			metadata.SrcIP = ip
+			metadata.SrcPort = port
+		}
+	}
+}
+
+// WithSpecialProxy pins metadata to a specific outbound/policy-group name,
+// bypassing rule matching entirely - used by inbounds configured with
+// specialProxy in the `inbounds:` section.
+func WithSpecialProxy(name string) Addition {
+	return func(metadata *C.Metadata) {
+		metadata.SpecialProxy = name
+	}
+}
@@ -0,0 +1,141 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/Dreamacro/clash/component/dialer"
+	C "github.com/Dreamacro/clash/constant"
+	"github.com/Dreamacro/clash/log"
+)
+
+// errNeedsQUIC marks Hysteria2 as configured but undialable: this tree has
+// no quic-go dependency built in yet (the same situation newDoHClient is in
+// for HTTP/3, see errNeedsHTTP3 in dns/doh.go). NewHysteria2 validates
+// every option up front, and DialContext/ListenPacketContext fail with
+// this clear, specific error instead of a confusing low-level connect
+// failure.
+var errNeedsQUIC = errors.New("hysteria2: requires the quic-go dependency, which isn't built into this binary")
+
+type Hysteria2 struct {
+	*Base
+	option *Hysteria2Option
+}
+
+type Hysteria2Option struct {
+	BasicOption
+	Name   string `proxy:"name"`
+	Server string `proxy:"server"`
+	Port   int    `proxy:"port"`
+	// Ports enables QUIC port-hopping: a comma-separated list of ports
+	// and/or "start-end" ranges the client round-robins across per
+	// connection attempt, defeating per-port UDP blocking.
+	Ports          string   `proxy:"ports,omitempty"`
+	Password       string   `proxy:"password"`
+	SNI            string   `proxy:"sni,omitempty"`
+	SkipCertVerify bool     `proxy:"skip-cert-verify,omitempty"`
+	Fingerprint    string   `proxy:"fingerprint,omitempty"`
+	ALPN           []string `proxy:"alpn,omitempty"`
+
+	// Obfs selects a UDP-level obfuscator applied underneath QUIC; "" and
+	// "salamander" are the only values the real protocol defines.
+	Obfs         string `proxy:"obfs,omitempty"`
+	ObfsPassword string `proxy:"obfs-password,omitempty"`
+
+	// Up/Down are bandwidth hints ("100 mbps") sent during the auth
+	// handshake so the server can size its BBR/Brutal congestion window;
+	// empty leaves it to the congestion controller's own probing.
+	Up   string `proxy:"up,omitempty"`
+	Down string `proxy:"down,omitempty"`
+
+	// CongestionController selects "bbr" (default, adaptive) or "brutal"
+	// (constant-rate, sized from Up/Down) congestion control.
+	CongestionController string `proxy:"congestion-controller,omitempty"`
+
+	UDP bool `proxy:"udp,omitempty"`
+}
+
+// DialContext implements C.ProxyAdapter
+func (h *Hysteria2) DialContext(ctx context.Context, metadata *C.Metadata, opts ...dialer.Option) (C.Conn, error) {
+	return nil, fmt.Errorf("%s connect error: %w", h.addr, errNeedsQUIC)
+}
+
+// ListenPacketContext implements C.ProxyAdapter
+func (h *Hysteria2) ListenPacketContext(ctx context.Context, metadata *C.Metadata, opts ...dialer.Option) (C.PacketConn, error) {
+	return nil, fmt.Errorf("%s listen error: %w", h.addr, errNeedsQUIC)
+}
+
+// SupportUOT reports whether this proxy carries UDP over TCP; a QUIC-based
+// proxy always carries UDP natively, so this is always false.
+func (h *Hysteria2) SupportUOT() bool {
+	return false
+}
+
+func NewHysteria2(option Hysteria2Option) (*Hysteria2, error) {
+	if option.Password == "" {
+		return nil, errors.New("hysteria2: password is required")
+	}
+
+	switch strings.ToLower(option.CongestionController) {
+	case "", "bbr", "brutal":
+	default:
+		return nil, fmt.Errorf("hysteria2: unsupported congestion-controller %q", option.CongestionController)
+	}
+
+	switch option.Obfs {
+	case "", "salamander":
+	default:
+		return nil, fmt.Errorf("hysteria2: unsupported obfs %q", option.Obfs)
+	}
+
+	if option.Obfs != "" && option.ObfsPassword == "" {
+		return nil, fmt.Errorf("hysteria2: obfs %q requires obfs-password", option.Obfs)
+	}
+
+	if option.Ports != "" {
+		if err := validatePortRanges(option.Ports); err != nil {
+			return nil, fmt.Errorf("hysteria2: ports: %w", err)
+		}
+	}
+
+	log.Warnln("proxy %s: hysteria2 requires the quic-go dependency, which isn't built into this binary - every dial through it will fail with %s", option.Name, errNeedsQUIC)
+
+	return &Hysteria2{
+		Base: &Base{
+			name:  option.Name,
+			addr:  net.JoinHostPort(option.Server, strconv.Itoa(option.Port)),
+			tp:    C.Hysteria2,
+			udp:   option.UDP,
+			iface: option.Interface,
+			rmark: option.RoutingMark,
+		},
+		option: &option,
+	}, nil
+}
+
+// validatePortRanges checks a comma-separated "port" / "start-end" port-hop
+// list for well-formedness without keeping the parsed result around - the
+// actual hop selection only matters once dialing is wired up.
+func validatePortRanges(ports string) error {
+	for _, part := range strings.Split(ports, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		start, end, isRange := strings.Cut(part, "-")
+		if _, err := strconv.Atoi(strings.TrimSpace(start)); err != nil {
+			return fmt.Errorf("invalid port %q: %w", part, err)
+		}
+		if isRange {
+			if _, err := strconv.Atoi(strings.TrimSpace(end)); err != nil {
+				return fmt.Errorf("invalid port range %q: %w", part, err)
+			}
+		}
+	}
+	return nil
+}
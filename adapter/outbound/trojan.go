@@ -11,6 +11,7 @@ import (
 	"golang.org/x/net/http2"
 
 	"github.com/Dreamacro/clash/component/dialer"
+	tlsC "github.com/Dreamacro/clash/component/tls"
 	C "github.com/Dreamacro/clash/constant"
 	"github.com/Dreamacro/clash/transport/gun"
 	"github.com/Dreamacro/clash/transport/trojan"
@@ -21,6 +22,8 @@ type Trojan struct {
 	*Base
 	instance *trojan.Trojan
 	option   *TrojanOption
+	reality  *tlsC.RealityConfig
+	pin      *[32]byte
 
 	// for gun mux
 	gunTLSConfig *tls.Config
@@ -30,22 +33,53 @@ type Trojan struct {
 
 type TrojanOption struct {
 	BasicOption
-	Name           string      `proxy:"name"`
-	Server         string      `proxy:"server"`
-	Port           int         `proxy:"port"`
-	Password       string      `proxy:"password"`
-	ALPN           []string    `proxy:"alpn,omitempty"`
-	SNI            string      `proxy:"sni,omitempty"`
-	SkipCertVerify bool        `proxy:"skip-cert-verify,omitempty"`
-	UDP            bool        `proxy:"udp,omitempty"`
-	Network        string      `proxy:"network,omitempty"`
-	GrpcOpts       GrpcOptions `proxy:"grpc-opts,omitempty"`
-	WSOpts         WSOptions   `proxy:"ws-opts,omitempty"`
-	Flow           string      `proxy:"flow,omitempty"`
-	FlowShow       bool        `proxy:"flow-show,omitempty"`
+	Name              string              `proxy:"name"`
+	Server            string              `proxy:"server"`
+	Port              int                 `proxy:"port"`
+	Password          string              `proxy:"password"`
+	ALPN              []string            `proxy:"alpn,omitempty"`
+	SNI               string              `proxy:"sni,omitempty"`
+	SkipCertVerify    bool                `proxy:"skip-cert-verify,omitempty"`
+	UDP               bool                `proxy:"udp,omitempty"`
+	Network           string              `proxy:"network,omitempty"`
+	GrpcOpts          GrpcOptions         `proxy:"grpc-opts,omitempty"`
+	WSOpts            WSOptions           `proxy:"ws-opts,omitempty"`
+	Flow              string              `proxy:"flow,omitempty"`
+	FlowShow          bool                `proxy:"flow-show,omitempty"`
+	RealityOpts       tlsC.RealityOptions `proxy:"reality-opts,omitempty"`
+	ClientFingerprint string              `proxy:"client-fingerprint,omitempty"`
+	Fingerprint       string              `proxy:"fingerprint,omitempty"`
 }
 
 func (t *Trojan) plainStream(c net.Conn) (net.Conn, error) {
+	if t.reality != nil && t.option.Network != "ws" {
+		sni := t.option.SNI
+		if sni == "" {
+			sni = t.option.Server
+		}
+		conn, err := tlsC.DialRealityConn(c, sni, t.reality, t.pin)
+		if err != nil {
+			return nil, fmt.Errorf("%s reality handshake: %w", t.addr, err)
+		}
+		return conn, nil
+	}
+
+	if t.option.ClientFingerprint != "" && t.option.Network != "ws" && t.option.Flow == "" {
+		sni := t.option.SNI
+		if sni == "" {
+			sni = t.option.Server
+		}
+		conn, err := tlsC.DialWithFingerprint(context.Background(), c, t.option.ClientFingerprint, &tls.Config{
+			ServerName:         sni,
+			InsecureSkipVerify: t.option.SkipCertVerify,
+			NextProtos:         t.option.ALPN,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s client-fingerprint handshake: %w", t.addr, err)
+		}
+		return conn, nil
+	}
+
 	if t.option.Network == "ws" {
 		host, port, _ := net.SplitHostPort(t.addr)
 		wsOpts := &trojan.WebsocketOption{
@@ -75,7 +109,11 @@ func (t *Trojan) plainStream(c net.Conn) (net.Conn, error) {
 func (t *Trojan) trojanStream(c net.Conn, metadata *C.Metadata) (net.Conn, error) {
 	var err error
 	if t.transport != nil {
-		c, err = gun.StreamGunWithConn(c, t.gunTLSConfig, t.gunConfig)
+		if t.option.ClientFingerprint != "" && t.option.Flow == "" {
+			c, err = gun.StreamGunWithUTLSConn(c, t.option.ClientFingerprint, t.gunTLSConfig, t.gunConfig)
+		} else {
+			c, err = gun.StreamGunWithConn(c, t.gunTLSConfig, t.gunConfig)
+		}
 	} else {
 		c, err = t.plainStream(c)
 	}
@@ -225,6 +263,30 @@ func NewTrojan(option TrojanOption) (*Trojan, error) {
 		tOption.ServerName = option.SNI
 	}
 
+	var reality *tlsC.RealityConfig
+	if option.RealityOpts.PublicKey != "" {
+		var err error
+		reality, err = option.RealityOpts.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("%s reality-opts: %w", addr, err)
+		}
+	}
+
+	var pin *[32]byte
+	if option.Fingerprint != "" {
+		fp, err := tlsC.ParseFingerprint(option.Fingerprint)
+		if err != nil {
+			return nil, fmt.Errorf("%s fingerprint: %w", addr, err)
+		}
+		pin = &fp
+	}
+
+	if option.ClientFingerprint != "" {
+		if _, err := tlsC.GetSpecFactory(option.ClientFingerprint); err != nil {
+			return nil, fmt.Errorf("%s client-fingerprint: %w", addr, err)
+		}
+	}
+
 	t := &Trojan{
 		Base: &Base{
 			name:  option.Name,
@@ -236,6 +298,8 @@ func NewTrojan(option TrojanOption) (*Trojan, error) {
 		},
 		instance: trojan.New(tOption),
 		option:   &option,
+		reality:  reality,
+		pin:      pin,
 	}
 
 	if option.Network == "grpc" {
@@ -255,9 +319,16 @@ func NewTrojan(option TrojanOption) (*Trojan, error) {
 			ServerName:         tOption.ServerName,
 		}
 
-		if t.option.Flow != "" {
+		if pin != nil {
+			tlsC.ApplyPin(tlsConfig, *pin)
+		}
+
+		switch {
+		case t.option.Flow != "":
 			t.transport = gun.NewHTTP2XTLSClient(dialFn, tlsConfig)
-		} else {
+		case option.ClientFingerprint != "":
+			t.transport = gun.NewHTTP2UTLSClient(dialFn, option.ClientFingerprint, tlsConfig)
+		default:
 			t.transport = gun.NewHTTP2Client(dialFn, tlsConfig)
 		}
 
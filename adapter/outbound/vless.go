@@ -16,6 +16,7 @@ import (
 
 	"github.com/Dreamacro/clash/component/dialer"
 	"github.com/Dreamacro/clash/component/resolver"
+	tlsC "github.com/Dreamacro/clash/component/tls"
 	C "github.com/Dreamacro/clash/constant"
 	"github.com/Dreamacro/clash/transport/gun"
 	"github.com/Dreamacro/clash/transport/socks5"
@@ -30,8 +31,10 @@ const (
 
 type Vless struct {
 	*Base
-	client *vless.Client
-	option *VlessOption
+	client  *vless.Client
+	option  *VlessOption
+	reality *tlsC.RealityConfig
+	pin     *[32]byte
 
 	// for gun mux
 	gunTLSConfig *tls.Config
@@ -41,22 +44,46 @@ type Vless struct {
 
 type VlessOption struct {
 	BasicOption
-	Name           string            `proxy:"name"`
-	Server         string            `proxy:"server"`
-	Port           int               `proxy:"port"`
-	UUID           string            `proxy:"uuid"`
-	Flow           string            `proxy:"flow,omitempty"`
-	FlowShow       bool              `proxy:"flow-show,omitempty"`
-	UDP            bool              `proxy:"udp,omitempty"`
-	Network        string            `proxy:"network,omitempty"`
-	HTTPOpts       HTTPOptions       `proxy:"http-opts,omitempty"`
-	HTTP2Opts      HTTP2Options      `proxy:"h2-opts,omitempty"`
-	GrpcOpts       GrpcOptions       `proxy:"grpc-opts,omitempty"`
-	WSOpts         WSOptions         `proxy:"ws-opts,omitempty"`
-	WSPath         string            `proxy:"ws-path,omitempty"`
-	WSHeaders      map[string]string `proxy:"ws-headers,omitempty"`
-	SkipCertVerify bool              `proxy:"skip-cert-verify,omitempty"`
-	ServerName     string            `proxy:"servername,omitempty"`
+	Name              string              `proxy:"name"`
+	Server            string              `proxy:"server"`
+	Port              int                 `proxy:"port"`
+	UUID              string              `proxy:"uuid"`
+	Flow              string              `proxy:"flow,omitempty"`
+	FlowShow          bool                `proxy:"flow-show,omitempty"`
+	UDP               bool                `proxy:"udp,omitempty"`
+	Network           string              `proxy:"network,omitempty"`
+	HTTPOpts          HTTPOptions         `proxy:"http-opts,omitempty"`
+	HTTP2Opts         HTTP2Options        `proxy:"h2-opts,omitempty"`
+	GrpcOpts          GrpcOptions         `proxy:"grpc-opts,omitempty"`
+	WSOpts            WSOptions           `proxy:"ws-opts,omitempty"`
+	WSPath            string              `proxy:"ws-path,omitempty"`
+	WSHeaders         map[string]string   `proxy:"ws-headers,omitempty"`
+	SkipCertVerify    bool                `proxy:"skip-cert-verify,omitempty"`
+	ServerName        string              `proxy:"servername,omitempty"`
+	RealityOpts       tlsC.RealityOptions `proxy:"reality-opts,omitempty"`
+	ClientFingerprint string              `proxy:"client-fingerprint,omitempty"`
+	Fingerprint       string              `proxy:"fingerprint,omitempty"`
+	PacketAddr        bool                `proxy:"packet-addr,omitempty"`
+	XUDP              bool                `proxy:"xudp,omitempty"`
+	PacketEncoding    string              `proxy:"packet-encoding,omitempty"`
+}
+
+// packetEncoding resolves the option's UDP framing: "packet-encoding" wins
+// when set, otherwise the older standalone "xudp"/"packet-addr" booleans
+// are honored for config compatibility, and the hand-rolled length-prefixed
+// framing (vlessPacketConn) is the default when neither is set.
+func (v *Vless) packetEncoding() string {
+	switch v.option.PacketEncoding {
+	case "xudp", "packetaddr":
+		return v.option.PacketEncoding
+	}
+	if v.option.XUDP {
+		return "xudp"
+	}
+	if v.option.PacketAddr {
+		return "packetaddr"
+	}
+	return ""
 }
 
 // StreamConn implements C.ProxyAdapter
@@ -131,9 +158,12 @@ func (v *Vless) StreamConn(c net.Conn, metadata *C.Metadata) (net.Conn, error) {
 
 		c, err = vmess.StreamH2Conn(c, h2Opts)
 	case "grpc":
-		if v.isXTLSEnabled() {
+		switch {
+		case v.reality != nil && !v.isXTLSEnabled():
+			c, err = gun.StreamGunWithRealityConn(c, v.reality, v.gunTLSConfig.ServerName, v.pin, v.gunConfig)
+		case v.isXTLSEnabled():
 			c, err = gun.StreamGunWithXTLSConn(c, v.gunTLSConfig, v.gunConfig)
-		} else {
+		default:
 			c, err = gun.StreamGunWithConn(c, v.gunTLSConfig, v.gunConfig)
 		}
 	default:
@@ -151,8 +181,12 @@ func (v *Vless) StreamConn(c net.Conn, metadata *C.Metadata) (net.Conn, error) {
 
 // StreamPacketConn implements C.ProxyAdapter
 func (v *Vless) StreamPacketConn(c net.Conn, metadata *C.Metadata) (net.Conn, error) {
-	// vmess use stream-oriented udp with a special address, so we need a net.UDPAddr
-	if !metadata.Resolved() {
+	encoding := v.packetEncoding()
+
+	// the legacy framing below is stream-oriented to a single destination,
+	// so it still needs a net.UDPAddr up front; packet-addr/xudp carry the
+	// destination on the wire instead and can stay domain-unresolved.
+	if encoding == "" && !metadata.Resolved() {
 		ip, err := resolver.ResolveFirstIP(metadata.Host)
 		if err != nil {
 			return nil, errors.New("can't resolve ip")
@@ -166,12 +200,24 @@ func (v *Vless) StreamPacketConn(c net.Conn, metadata *C.Metadata) (net.Conn, er
 		return nil, fmt.Errorf("new vmess client error: %v", err)
 	}
 
-	return WrapConn(&vlessPacketConn{Conn: c, rAddr: metadata.UDPAddr()}), nil
+	return WrapConn(v.newVlessPacketConn(c, metadata)), nil
 }
 
 func (v *Vless) streamTLSOrXTLSConn(conn net.Conn, isH2 bool) (net.Conn, error) {
 	host, _, _ := net.SplitHostPort(v.addr)
 
+	if v.reality != nil && !v.isXTLSEnabled() {
+		sni := v.option.ServerName
+		if sni == "" {
+			sni = host
+		}
+		conn, err := tlsC.DialRealityConn(conn, sni, v.reality, v.pin)
+		if err != nil {
+			return nil, fmt.Errorf("%s reality handshake: %w", v.addr, err)
+		}
+		return conn, nil
+	}
+
 	if v.isXTLSEnabled() {
 		xtlsOpts := vless.XTLSConfig{
 			Host:           host,
@@ -210,6 +256,14 @@ func (v *Vless) isXTLSEnabled() bool {
 	return v.client.Addons != nil
 }
 
+// SupportsUDPDomain reports whether this outbound's UDP framing carries the
+// destination domain on the wire, letting tunnel.handleUDPConn skip its own
+// local DNS resolution. Only "xudp" does; "packetaddr" still needs a
+// resolved net.Addr to put in each datagram's prefix.
+func (v *Vless) SupportsUDPDomain() bool {
+	return v.packetEncoding() == "xudp"
+}
+
 // DialContext implements C.ProxyAdapter
 func (v *Vless) DialContext(ctx context.Context, metadata *C.Metadata, opts ...dialer.Option) (_ C.Conn, err error) {
 	// gun transport
@@ -244,8 +298,12 @@ func (v *Vless) ListenPacketContext(ctx context.Context, metadata *C.Metadata, o
 	var c net.Conn
 	// gun transport
 	if v.transport != nil && len(opts) == 0 {
-		// vless use stream-oriented udp with a special address, so we need a net.UDPAddr
-		if !metadata.Resolved() {
+		encoding := v.packetEncoding()
+
+		// the legacy framing is stream-oriented to a single destination, so
+		// it still needs a net.UDPAddr; packet-addr/xudp carry the
+		// destination on the wire instead and can stay domain-unresolved.
+		if encoding == "" && !metadata.Resolved() {
 			ip, err := resolver.ResolveFirstIP(metadata.Host)
 			if err != nil {
 				return nil, errors.New("can't resolve ip")
@@ -264,7 +322,7 @@ func (v *Vless) ListenPacketContext(ctx context.Context, metadata *C.Metadata, o
 			return nil, fmt.Errorf("new vless client error: %v", err)
 		}
 
-		return NewPacketConn(&vlessPacketConn{Conn: c, rAddr: metadata.UDPAddr()}, v), nil
+		return NewPacketConn(v.newVlessPacketConn(c, metadata).(net.PacketConn), v), nil
 	}
 
 	c, err = dialer.DialContext(ctx, "tcp", v.addr, v.Base.DialOptions(opts...)...)
@@ -422,6 +480,29 @@ func NewVless(option VlessOption) (*Vless, error) {
 		return nil, err
 	}
 
+	var reality *tlsC.RealityConfig
+	if option.RealityOpts.PublicKey != "" {
+		reality, err = option.RealityOpts.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("%s reality-opts: %w", option.Server, err)
+		}
+	}
+
+	var pin *[32]byte
+	if option.Fingerprint != "" {
+		fp, err := tlsC.ParseFingerprint(option.Fingerprint)
+		if err != nil {
+			return nil, fmt.Errorf("%s fingerprint: %w", option.Server, err)
+		}
+		pin = &fp
+	}
+
+	if option.ClientFingerprint != "" {
+		if _, err := tlsC.GetSpecFactory(option.ClientFingerprint); err != nil {
+			return nil, fmt.Errorf("%s client-fingerprint: %w", option.Server, err)
+		}
+	}
+
 	v := &Vless{
 		Base: &Base{
 			name:  option.Name,
@@ -430,8 +511,10 @@ func NewVless(option VlessOption) (*Vless, error) {
 			udp:   option.UDP,
 			iface: option.Interface,
 		},
-		client: client,
-		option: &option,
+		client:  client,
+		option:  &option,
+		reality: reality,
+		pin:     pin,
 	}
 
 	switch option.Network {
@@ -464,11 +547,18 @@ func NewVless(option VlessOption) (*Vless, error) {
 			gunConfig.Host = host
 		}
 
+		if pin != nil {
+			tlsC.ApplyPin(tlsConfig, *pin)
+		}
+
 		v.gunTLSConfig = tlsConfig
 		v.gunConfig = gunConfig
-		if v.isXTLSEnabled() {
+		switch {
+		case reality != nil && !v.isXTLSEnabled():
+			v.transport = gun.NewHTTP2RealityClient(dialFn, reality, tlsConfig.ServerName, pin)
+		case v.isXTLSEnabled():
 			v.transport = gun.NewHTTP2XTLSClient(dialFn, tlsConfig)
-		} else {
+		default:
 			v.transport = gun.NewHTTP2Client(dialFn, tlsConfig)
 		}
 	}
@@ -0,0 +1,235 @@
+package outbound
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	C "github.com/Dreamacro/clash/constant"
+	"github.com/Dreamacro/clash/transport/socks5"
+)
+
+// newVlessPacketConn picks the UDP framing for c according to v's resolved
+// packetEncoding(): the legacy single-destination vlessPacketConn, the
+// SOCKS-style per-datagram destination of "packetaddr", or the sing-vmess
+// "xudp" framing. All three implement both net.Conn and net.PacketConn.
+func (v *Vless) newVlessPacketConn(c net.Conn, metadata *C.Metadata) net.Conn {
+	switch v.packetEncoding() {
+	case "xudp":
+		return &xudpPacketConn{Conn: c}
+	case "packetaddr":
+		return &packetAddrPacketConn{Conn: c}
+	default:
+		return &vlessPacketConn{Conn: c, rAddr: metadata.UDPAddr()}
+	}
+}
+
+// writeSocksAddr encodes addr (a *net.UDPAddr, as every caller in this file
+// supplies) into the same atyp+addr+port wire form vless.DstAddr uses.
+func writeSocksAddr(addr net.Addr) (socks5.Addr, error) {
+	host, portStr, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, fmt.Errorf("vless: unresolved destination address %q", addr.String())
+	}
+
+	var buf []byte
+	if ip4 := ip.To4(); ip4 != nil {
+		buf = make([]byte, 1+net.IPv4len+2)
+		buf[0] = socks5.AtypIPv4
+		copy(buf[1:], ip4)
+	} else {
+		buf = make([]byte, 1+net.IPv6len+2)
+		buf[0] = socks5.AtypIPv6
+		copy(buf[1:], ip.To16())
+	}
+	binary.BigEndian.PutUint16(buf[len(buf)-2:], uint16(port))
+	return buf, nil
+}
+
+// readSocksAddr reads one atyp+addr+port target off r and resolves it to a
+// *net.UDPAddr for ReadFrom's return value.
+func readSocksAddr(r io.Reader) (*net.UDPAddr, error) {
+	var atyp [1]byte
+	if _, err := io.ReadFull(r, atyp[:]); err != nil {
+		return nil, err
+	}
+
+	var ip net.IP
+	switch atyp[0] {
+	case socks5.AtypIPv4:
+		buf := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		ip = net.IP(buf)
+	case socks5.AtypIPv6:
+		buf := make([]byte, net.IPv6len)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		ip = net.IP(buf)
+	case socks5.AtypDomainName:
+		var length [1]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return nil, err
+		}
+		host := make([]byte, length[0])
+		if _, err := io.ReadFull(r, host); err != nil {
+			return nil, err
+		}
+		resolved, err := net.ResolveIPAddr("ip", string(host))
+		if err != nil {
+			return nil, err
+		}
+		ip = resolved.IP
+	default:
+		return nil, fmt.Errorf("vless: unsupported address type: %#x", atyp[0])
+	}
+
+	var port [2]byte
+	if _, err := io.ReadFull(r, port[:]); err != nil {
+		return nil, err
+	}
+	return &net.UDPAddr{IP: ip, Port: int(binary.BigEndian.Uint16(port[:]))}, nil
+}
+
+func readLengthPrefixed(r io.Reader, b []byte) (int, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return 0, err
+	}
+	total := int(binary.BigEndian.Uint16(length[:]))
+
+	n := total
+	if n > len(b) {
+		n = len(b)
+	}
+	if _, err := io.ReadFull(r, b[:n]); err != nil {
+		return 0, err
+	}
+	if n < total {
+		if _, err := io.CopyN(io.Discard, r, int64(total-n)); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// packetAddrPacketConn prefixes every datagram with its own SOCKS-style
+// destination address (RFC 1928 ATYP/addr/port), so a single VLESS UDP
+// stream can carry packets to more than one remote instead of being pinned
+// to whatever address the stream was opened for.
+type packetAddrPacketConn struct {
+	net.Conn
+	mux sync.Mutex
+}
+
+func (pc *packetAddrPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	target, err := writeSocksAddr(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	pc.mux.Lock()
+	defer pc.mux.Unlock()
+
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(b)))
+
+	if _, err := pc.Conn.Write(append(append([]byte{}, target...), length[:]...)); err != nil {
+		return 0, err
+	}
+	return pc.Conn.Write(b)
+}
+
+func (pc *packetAddrPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	addr, err := readSocksAddr(pc.Conn)
+	if err != nil {
+		return 0, nil, err
+	}
+	n, err := readLengthPrefixed(pc.Conn, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, addr, nil
+}
+
+// xudpPacketConn implements the sing-vmess "XUDP" framing used by servers
+// like Xray: a 16-byte session ID sent once up front identifies this
+// logical UDP association to the server, and each datagram then carries
+// its own addr+length prefix (the same shape as packet-addr).
+type xudpPacketConn struct {
+	net.Conn
+	mux       sync.Mutex
+	wroteID   bool
+	readID    bool
+	sessionID [16]byte
+}
+
+func (xc *xudpPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	target, err := writeSocksAddr(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	xc.mux.Lock()
+	defer xc.mux.Unlock()
+
+	frame := make([]byte, 0, 16+len(target)+2)
+	if !xc.wroteID {
+		if _, err := rand.Read(xc.sessionID[:]); err != nil {
+			return 0, err
+		}
+		frame = append(frame, xc.sessionID[:]...)
+		xc.wroteID = true
+	}
+	frame = append(frame, target...)
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(b)))
+	frame = append(frame, length[:]...)
+
+	if _, err := xc.Conn.Write(frame); err != nil {
+		return 0, err
+	}
+	return xc.Conn.Write(b)
+}
+
+func (xc *xudpPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	xc.mux.Lock()
+	needsID := !xc.readID
+	xc.mux.Unlock()
+
+	if needsID {
+		var id [16]byte
+		if _, err := io.ReadFull(xc.Conn, id[:]); err != nil {
+			return 0, nil, err
+		}
+		xc.mux.Lock()
+		xc.sessionID = id
+		xc.readID = true
+		xc.mux.Unlock()
+	}
+
+	addr, err := readSocksAddr(xc.Conn)
+	if err != nil {
+		return 0, nil, err
+	}
+	n, err := readLengthPrefixed(xc.Conn, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, addr, nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/Dreamacro/clash/common/convert"
 	"github.com/Dreamacro/clash/component/dialer"
 	"github.com/Dreamacro/clash/component/resolver"
+	tlsC "github.com/Dreamacro/clash/component/tls"
 	C "github.com/Dreamacro/clash/constant"
 	"github.com/Dreamacro/clash/transport/gun"
 	"github.com/Dreamacro/clash/transport/socks5"
@@ -22,8 +23,10 @@ import (
 
 type Vmess struct {
 	*Base
-	client *vmess.Client
-	option *VmessOption
+	client  *vmess.Client
+	option  *VmessOption
+	reality *tlsC.RealityConfig
+	pin     *[32]byte
 
 	// for gun mux
 	gunTLSConfig *tls.Config
@@ -49,6 +52,9 @@ type VmessOption struct {
 	GrpcOpts       GrpcOptions  `proxy:"grpc-opts,omitempty"`
 	WSOpts         WSOptions    `proxy:"ws-opts,omitempty"`
 
+	RealityOpts       tlsC.RealityOptions `proxy:"reality-opts,omitempty"`
+	ClientFingerprint string              `proxy:"client-fingerprint,omitempty"`
+
 	// TODO: compatible with VMESS WS older version configurations
 	WSHeaders map[string]string `proxy:"ws-headers,omitempty"`
 	WSPath    string            `proxy:"ws-path,omitempty"`
@@ -105,16 +111,25 @@ func (v *Vmess) StreamConn(c net.Conn, metadata *C.Metadata) (net.Conn, error) {
 		}
 
 		if v.option.TLS {
-			wsOpts.TLS = true
-			wsOpts.TLSConfig = &tls.Config{
+			tlsConfig := &tls.Config{
 				ServerName:         host,
 				InsecureSkipVerify: v.option.SkipCertVerify,
 				NextProtos:         []string{"http/1.1"},
 			}
 			if v.option.ServerName != "" {
-				wsOpts.TLSConfig.ServerName = v.option.ServerName
+				tlsConfig.ServerName = v.option.ServerName
 			} else if host := wsOpts.Headers.Get("Host"); host != "" {
-				wsOpts.TLSConfig.ServerName = host
+				tlsConfig.ServerName = host
+			}
+
+			if v.option.ClientFingerprint != "" {
+				c, err = tlsC.DialWithFingerprint(context.Background(), c, v.option.ClientFingerprint, tlsConfig)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				wsOpts.TLS = true
+				wsOpts.TLSConfig = tlsConfig
 			}
 		} else {
 			if wsOpts.Headers.Get("Host") == "" {
@@ -153,17 +168,24 @@ func (v *Vmess) StreamConn(c net.Conn, metadata *C.Metadata) (net.Conn, error) {
 		c = vmess.StreamHTTPConn(c, httpOpts)
 	case "h2":
 		host, _, _ := net.SplitHostPort(v.addr)
-		tlsOpts := vmess.TLSConfig{
-			Host:           host,
-			SkipCertVerify: v.option.SkipCertVerify,
-			NextProtos:     []string{"h2"},
-		}
-
+		sni := host
 		if v.option.ServerName != "" {
-			tlsOpts.Host = v.option.ServerName
+			sni = v.option.ServerName
 		}
 
-		c, err = vmess.StreamTLSConn(c, &tlsOpts)
+		if v.option.ClientFingerprint != "" {
+			c, err = tlsC.DialWithFingerprint(context.Background(), c, v.option.ClientFingerprint, &tls.Config{
+				ServerName:         sni,
+				InsecureSkipVerify: v.option.SkipCertVerify,
+				NextProtos:         []string{"h2"},
+			})
+		} else {
+			c, err = vmess.StreamTLSConn(c, &vmess.TLSConfig{
+				Host:           sni,
+				SkipCertVerify: v.option.SkipCertVerify,
+				NextProtos:     []string{"h2"},
+			})
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -175,21 +197,35 @@ func (v *Vmess) StreamConn(c net.Conn, metadata *C.Metadata) (net.Conn, error) {
 
 		c, err = vmess.StreamH2Conn(c, h2Opts)
 	case "grpc":
-		c, err = gun.StreamGunWithConn(c, v.gunTLSConfig, v.gunConfig)
+		switch {
+		case v.reality != nil:
+			c, err = gun.StreamGunWithRealityConn(c, v.reality, v.gunTLSConfig.ServerName, v.pin, v.gunConfig)
+		case v.option.ClientFingerprint != "":
+			c, err = gun.StreamGunWithUTLSConn(c, v.option.ClientFingerprint, v.gunTLSConfig, v.gunConfig)
+		default:
+			c, err = gun.StreamGunWithConn(c, v.gunTLSConfig, v.gunConfig)
+		}
 	default:
-		// handle TLS
-		if v.option.TLS {
-			host, _, _ := net.SplitHostPort(v.addr)
-			tlsOpts := &vmess.TLSConfig{
-				Host:           host,
-				SkipCertVerify: v.option.SkipCertVerify,
-			}
-
-			if v.option.ServerName != "" {
-				tlsOpts.Host = v.option.ServerName
-			}
+		// handle TLS, REALITY And client-fingerprint
+		host, _, _ := net.SplitHostPort(v.addr)
+		sni := v.option.ServerName
+		if sni == "" {
+			sni = host
+		}
 
-			c, err = vmess.StreamTLSConn(c, tlsOpts)
+		switch {
+		case v.reality != nil:
+			c, err = tlsC.DialRealityConn(c, sni, v.reality, v.pin)
+		case v.option.TLS && v.option.ClientFingerprint != "":
+			c, err = tlsC.DialWithFingerprint(context.Background(), c, v.option.ClientFingerprint, &tls.Config{
+				ServerName:         sni,
+				InsecureSkipVerify: v.option.SkipCertVerify,
+			})
+		case v.option.TLS:
+			c, err = vmess.StreamTLSConn(c, &vmess.TLSConfig{
+				Host:           sni,
+				SkipCertVerify: v.option.SkipCertVerify,
+			})
 		}
 	}
 
@@ -314,6 +350,20 @@ func NewVmess(option VmessOption) (*Vmess, error) {
 		}
 	}
 
+	var reality *tlsC.RealityConfig
+	if option.RealityOpts.PublicKey != "" {
+		reality, err = option.RealityOpts.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("%s reality-opts: %w", option.Server, err)
+		}
+	}
+
+	if option.ClientFingerprint != "" {
+		if _, err := tlsC.GetSpecFactory(option.ClientFingerprint); err != nil {
+			return nil, fmt.Errorf("%s client-fingerprint: %w", option.Server, err)
+		}
+	}
+
 	v := &Vmess{
 		Base: &Base{
 			name:  option.Name,
@@ -323,8 +373,9 @@ func NewVmess(option VmessOption) (*Vmess, error) {
 			iface: option.Interface,
 			rmark: option.RoutingMark,
 		},
-		client: client,
-		option: &option,
+		client:  client,
+		option:  &option,
+		reality: reality,
 	}
 
 	switch option.Network {
@@ -359,7 +410,14 @@ func NewVmess(option VmessOption) (*Vmess, error) {
 
 		v.gunTLSConfig = tlsConfig
 		v.gunConfig = gunConfig
-		v.transport = gun.NewHTTP2Client(dialFn, tlsConfig)
+		switch {
+		case reality != nil:
+			v.transport = gun.NewHTTP2RealityClient(dialFn, reality, tlsConfig.ServerName, v.pin)
+		case option.ClientFingerprint != "":
+			v.transport = gun.NewHTTP2UTLSClient(dialFn, option.ClientFingerprint, tlsConfig)
+		default:
+			v.transport = gun.NewHTTP2Client(dialFn, tlsConfig)
+		}
 	}
 
 	return v, nil
@@ -2,31 +2,75 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
 	"time"
 
 	"go.uber.org/atomic"
 
 	"github.com/Dreamacro/clash/common/batch"
+	"github.com/Dreamacro/clash/common/utils"
 	C "github.com/Dreamacro/clash/constant"
 )
 
 const (
 	defaultURLTestTimeout = time.Second * 5
+	statsWindowSize       = 10
 )
 
+// HealthCheckOption configures a HealthCheck. URLs are tried in order on
+// each tick, the first success winning, so a provider can fall back to a
+// mirror when the primary test URL is itself blocked.
 type HealthCheckOption struct {
-	URL      string
-	Interval uint
+	URLs        []string
+	Interval    uint
+	Timeout     time.Duration
+	Concurrency int
+
+	// ExpectedStatus, if non-empty, makes a HEAD response whose status code
+	// falls outside these ranges count as a failure even though the
+	// connection itself succeeded - this is what catches a captive portal
+	// or block page answering behind an otherwise-healthy TCP handshake.
+	ExpectedStatus utils.IntRanges[uint16]
+}
+
+// HealthStats is the rolling health-check history kept for a single proxy.
+type HealthStats struct {
+	RTTs        []uint16 // last statsWindowSize round-trip times, in ms
+	Successes   int
+	Failures    int
+	LastFailure string
+	LastSuccess bool
+}
+
+// SuccessRatio returns the fraction of recorded checks that succeeded, or 1
+// when no checks have run yet.
+func (s HealthStats) SuccessRatio() float64 {
+	total := s.Successes + s.Failures
+	if total == 0 {
+		return 1
+	}
+	return float64(s.Successes) / float64(total)
 }
 
 type HealthCheck struct {
-	url       string
-	proxies   []C.Proxy
-	interval  uint
-	lazy      bool
-	lastTouch *atomic.Int64
-	running   *atomic.Bool
-	done      chan struct{}
+	url            string
+	urls           []string
+	proxies        []C.Proxy
+	interval       uint
+	timeout        time.Duration
+	concurrency    int
+	lazy           bool
+	expectedStatus utils.IntRanges[uint16]
+	lastTouch      *atomic.Int64
+	running        *atomic.Bool
+	done           chan struct{}
+
+	statsMux sync.Mutex
+	stats    map[string]*HealthStats
 }
 
 func (hc *HealthCheck) process() {
@@ -35,7 +79,7 @@ func (hc *HealthCheck) process() {
 	}
 	hc.running.Store(true)
 
-	ticker := time.NewTicker(time.Duration(hc.interval) * time.Second)
+	ticker := time.NewTicker(hc.nextInterval())
 
 	go func() {
 		t := time.NewTicker(30 * time.Second)
@@ -51,6 +95,7 @@ func (hc *HealthCheck) process() {
 			if !hc.lazy || now-hc.lastTouch.Load() < int64(hc.interval) {
 				hc.check()
 			}
+			ticker.Reset(hc.nextInterval())
 		case <-hc.done:
 			ticker.Stop()
 			return
@@ -58,6 +103,15 @@ func (hc *HealthCheck) process() {
 	}
 }
 
+// nextInterval jitters the configured interval by up to ±10% so that many
+// providers configured with the same interval don't all hit their test
+// URL(s) in lockstep.
+func (hc *HealthCheck) nextInterval() time.Duration {
+	base := time.Duration(hc.interval) * time.Second
+	jitter := time.Duration(rand.Int63n(int64(base)/5+1)) - base/10
+	return base + jitter
+}
+
 func (hc *HealthCheck) setProxy(proxies []C.Proxy) {
 	hc.proxies = proxies
 }
@@ -70,26 +124,148 @@ func (hc *HealthCheck) touch() {
 	hc.lastTouch.Store(time.Now().Unix())
 }
 
+// Stats returns the rolling health-check history for the named proxy.
+func (hc *HealthCheck) Stats(name string) HealthStats {
+	hc.statsMux.Lock()
+	defer hc.statsMux.Unlock()
+
+	if s, ok := hc.stats[name]; ok {
+		return *s
+	}
+	return HealthStats{}
+}
+
+func (hc *HealthCheck) record(name string, rtt uint16, err error) {
+	hc.statsMux.Lock()
+	defer hc.statsMux.Unlock()
+
+	s, ok := hc.stats[name]
+	if !ok {
+		s = &HealthStats{}
+		hc.stats[name] = s
+	}
+
+	if err != nil {
+		s.Failures++
+		s.LastFailure = err.Error()
+		s.LastSuccess = false
+		return
+	}
+
+	s.Successes++
+	s.LastSuccess = true
+	s.RTTs = append(s.RTTs, rtt)
+	if len(s.RTTs) > statsWindowSize {
+		s.RTTs = s.RTTs[len(s.RTTs)-statsWindowSize:]
+	}
+}
+
+// StatsForProxy looks up the rolling health-check history for a proxy by
+// name across every HealthCheck currently registered (each proxy provider
+// owns one), for consumers like the Prometheus exporter that only have the
+// proxy's name, not a handle on the provider that owns its HealthCheck.
+//
+// registry is walked newest-first so that a reload racing a not-yet-closed
+// old HealthCheck still resolves to the current provider's stats rather
+// than whichever instance happens to come first.
+func StatsForProxy(name string) (HealthStats, bool) {
+	registryMux.RLock()
+	defer registryMux.RUnlock()
+
+	for i := len(registry) - 1; i >= 0; i-- {
+		hc := registry[i]
+		hc.statsMux.Lock()
+		s, ok := hc.stats[name]
+		if ok {
+			stats := *s
+			hc.statsMux.Unlock()
+			return stats, true
+		}
+		hc.statsMux.Unlock()
+	}
+	return HealthStats{}, false
+}
+
+// testOnce tries each configured URL in turn, the first success winning,
+// so a single blocked test endpoint doesn't mark an otherwise-healthy
+// proxy as down.
+func (hc *HealthCheck) testOnce(ctx context.Context, proxy C.Proxy) (uint16, error) {
+	var err error
+	for _, url := range hc.urls {
+		var rtt uint16
+		rtt, err = hc.probe(ctx, proxy, url)
+		if err == nil {
+			return rtt, nil
+		}
+	}
+	return 0, err
+}
+
+// probe runs the plain connection/latency test when expectedStatus is not
+// configured. Otherwise it sends its own HEAD request over the proxy
+// instead, timing that request and failing the probe if the response status
+// code falls outside the configured ranges even though the connection
+// itself succeeded.
+func (hc *HealthCheck) probe(ctx context.Context, proxy C.Proxy, url string) (uint16, error) {
+	if len(hc.expectedStatus) == 0 {
+		return proxy.URLTest(ctx, url)
+	}
+
+	start := time.Now()
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				host, port, err := net.SplitHostPort(addr)
+				if err != nil {
+					return nil, err
+				}
+				return proxy.DialContext(ctx, &C.Metadata{Host: host, DstPort: port})
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+
+	if !hc.expectedStatus.Check(uint16(resp.StatusCode)) {
+		return 0, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return uint16(time.Since(start).Milliseconds()), nil
+}
+
 func (hc *HealthCheck) check() {
 	proxies := hc.proxies
 	if len(proxies) == 0 {
 		return
 	}
 
-	b, _ := batch.New[bool](context.Background(), batch.WithConcurrencyNum[bool](10))
+	b, ctx := batch.New[bool](context.Background(), batch.WithConcurrencyNum[bool](hc.concurrency))
 	for _, proxy := range proxies {
 		p := proxy
 		b.Go(p.Name(), func() (bool, error) {
-			ctx, cancel := context.WithTimeout(context.Background(), defaultURLTestTimeout)
+			probeCtx, cancel := context.WithTimeout(ctx, hc.timeout)
 			defer cancel()
-			_, _ = p.URLTest(ctx, hc.url)
+			rtt, err := hc.testOnce(probeCtx, p)
+			hc.record(p.Name(), rtt, err)
 			return false, nil
 		})
 	}
-	b.Wait()
+	_, _ = b.Wait()
 }
 
 func (hc *HealthCheck) close() {
+	unregister(hc)
+
 	if !hc.running.Load() {
 		return
 	}
@@ -97,14 +273,60 @@ func (hc *HealthCheck) close() {
 	hc.done <- struct{}{}
 }
 
-func NewHealthCheck(proxies []C.Proxy, url string, interval uint, lazy bool) *HealthCheck {
-	return &HealthCheck{
-		proxies:   proxies,
-		url:       url,
-		interval:  interval,
-		lazy:      lazy,
-		lastTouch: atomic.NewInt64(0),
-		running:   atomic.NewBool(false),
-		done:      make(chan struct{}, 1),
+// unregister removes hc from registry, so a closed HealthCheck's stale
+// stats can no longer shadow its provider's replacement in StatsForProxy,
+// and the registry doesn't grow unbounded across config reloads.
+func unregister(hc *HealthCheck) {
+	registryMux.Lock()
+	defer registryMux.Unlock()
+
+	for i, entry := range registry {
+		if entry == hc {
+			registry = append(registry[:i], registry[i+1:]...)
+			return
+		}
+	}
+}
+
+var (
+	registryMux sync.RWMutex
+	registry    []*HealthCheck
+)
+
+func NewHealthCheck(proxies []C.Proxy, opt HealthCheckOption, lazy bool) *HealthCheck {
+	timeout := opt.Timeout
+	if timeout <= 0 {
+		timeout = defaultURLTestTimeout
 	}
+
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 10
+	}
+
+	var url string
+	if len(opt.URLs) > 0 {
+		url = opt.URLs[0]
+	}
+
+	hc := &HealthCheck{
+		proxies:        proxies,
+		url:            url,
+		urls:           opt.URLs,
+		interval:       opt.Interval,
+		timeout:        timeout,
+		concurrency:    concurrency,
+		lazy:           lazy,
+		expectedStatus: opt.ExpectedStatus,
+		lastTouch:      atomic.NewInt64(0),
+		running:        atomic.NewBool(false),
+		done:           make(chan struct{}, 1),
+		stats:          make(map[string]*HealthStats),
+	}
+
+	registryMux.Lock()
+	registry = append(registry, hc)
+	registryMux.Unlock()
+
+	return hc
 }
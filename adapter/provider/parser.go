@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Dreamacro/clash/common/structure"
+	"github.com/Dreamacro/clash/common/utils"
 	C "github.com/Dreamacro/clash/constant"
 	types "github.com/Dreamacro/clash/constant/provider"
 )
@@ -13,10 +14,14 @@ import (
 var errVehicleType = errors.New("unsupport vehicle type")
 
 type healthCheckSchema struct {
-	Enable   bool   `provider:"enable"`
-	URL      string `provider:"url"`
-	Interval int    `provider:"interval"`
-	Lazy     bool   `provider:"lazy,omitempty"`
+	Enable         bool     `provider:"enable"`
+	URL            string   `provider:"url,omitempty"`
+	URLs           []string `provider:"urls,omitempty"`
+	Interval       int      `provider:"interval"`
+	Timeout        int      `provider:"timeout,omitempty"`
+	Concurrency    int      `provider:"concurrency,omitempty"`
+	Lazy           bool     `provider:"lazy,omitempty"`
+	ExpectedStatus string   `provider:"expected-status,omitempty"`
 }
 
 type proxyProviderSchema struct {
@@ -26,6 +31,8 @@ type proxyProviderSchema struct {
 	URLProxy        bool                `provider:"url-proxy,omitempty"`
 	Interval        int                 `provider:"interval,omitempty"`
 	Filter          string              `provider:"filter,omitempty"`
+	ExcludeFilter   string              `provider:"exclude-filter,omitempty"`
+	ExcludeType     []string            `provider:"exclude-type,omitempty"`
 	HealthCheck     healthCheckSchema   `provider:"health-check,omitempty"`
 	ForceCertVerify bool                `provider:"force-cert-verify,omitempty"`
 	PrefixName      string              `provider:"prefix-name,omitempty"`
@@ -53,7 +60,24 @@ func ParseProxyProvider(name string, mapping map[string]any, forceCertVerify boo
 	if schema.HealthCheck.Enable {
 		hcInterval = uint(schema.HealthCheck.Interval)
 	}
-	hc := NewHealthCheck([]C.Proxy{}, schema.HealthCheck.URL, hcInterval, schema.HealthCheck.Lazy)
+
+	urls := schema.HealthCheck.URLs
+	if len(urls) == 0 && schema.HealthCheck.URL != "" {
+		urls = []string{schema.HealthCheck.URL}
+	}
+
+	expectedStatus, err := utils.NewIntRanges[uint16](schema.HealthCheck.ExpectedStatus)
+	if err != nil {
+		return nil, fmt.Errorf("health-check.expected-status: %w", err)
+	}
+
+	hc := NewHealthCheck([]C.Proxy{}, HealthCheckOption{
+		URLs:           urls,
+		Interval:       hcInterval,
+		Timeout:        time.Duration(schema.HealthCheck.Timeout) * time.Second,
+		Concurrency:    schema.HealthCheck.Concurrency,
+		ExpectedStatus: expectedStatus,
+	}, schema.HealthCheck.Lazy)
 
 	path := C.Path.Resolve(schema.Path)
 
@@ -69,5 +93,5 @@ func ParseProxyProvider(name string, mapping map[string]any, forceCertVerify boo
 
 	interval := time.Duration(uint(schema.Interval)) * time.Second
 	filter := schema.Filter
-	return NewProxySetProvider(name, interval, filter, vehicle, hc, schema.ForceCertVerify, schema.PrefixName)
-}
\ No newline at end of file
+	return NewProxySetProvider(name, interval, filter, schema.ExcludeFilter, schema.ExcludeType, vehicle, hc, schema.ForceCertVerify, schema.PrefixName)
+}
@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"github.com/Dreamacro/clash/common/convert"
+	"github.com/Dreamacro/clash/log"
+	"gopkg.in/yaml.v3"
+)
+
+// convertSubscription rewrites a non-Clash subscription payload - a single
+// base64 blob or a plaintext list of vmess/vless/trojan/ss/ssr/hysteria
+// URIs - into the `proxies:` YAML a ProxySetProvider already knows how to
+// parse. A payload that's already Clash YAML (or that didn't yield any
+// recognisable proxy line) passes through unchanged.
+func convertSubscription(buf []byte) []byte {
+	proxies, errs := convert.ConvertsV2Ray(buf)
+	for _, err := range errs {
+		log.Warnln("[Provider] subscription conversion: %s", err)
+	}
+
+	if len(proxies) == 0 {
+		return buf
+	}
+
+	out, err := yaml.Marshal(map[string]any{"proxies": proxies})
+	if err != nil {
+		log.Warnln("[Provider] marshal converted subscription: %s", err)
+		return buf
+	}
+
+	return out
+}
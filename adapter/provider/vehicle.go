@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net"
 	"net/http"
@@ -30,15 +31,69 @@ func (f *FileVehicle) Read() ([]byte, error) {
 	return os.ReadFile(f.path)
 }
 
+// LastUpdate is the file's own mtime - a FileVehicle has no separate cache
+// metadata the way HTTPVehicle does.
+func (f *FileVehicle) LastUpdate() time.Time {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
 func NewFileVehicle(path string) *FileVehicle {
 	return &FileVehicle{path: path}
 }
 
+// httpVehicleMeta is HTTPVehicle's conditional-fetch state, persisted
+// alongside the cached body at h.path + ".meta" so a restart doesn't lose
+// the ETag/Last-Modified validators and force a full re-fetch.
+type httpVehicleMeta struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+func (h *HTTPVehicle) metaPath() string {
+	return h.path + ".meta"
+}
+
+func (h *HTTPVehicle) readMeta() httpVehicleMeta {
+	var meta httpVehicleMeta
+	raw, err := os.ReadFile(h.metaPath())
+	if err != nil {
+		return meta
+	}
+	_ = json.Unmarshal(raw, &meta)
+	return meta
+}
+
+func (h *HTTPVehicle) writeMeta(meta httpVehicleMeta) {
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(h.metaPath(), raw, 0o644)
+}
+
 type HTTPVehicle struct {
-	path     string
-	url      string
-	urlProxy bool
-	header   http.Header
+	path      string
+	url       string
+	urlProxy  bool
+	header    http.Header
+	unchanged bool
+}
+
+// Unchanged reports whether the most recent Read returned the 304-cached
+// body unmodified, so a provider fetcher can skip re-parsing it.
+func (h *HTTPVehicle) Unchanged() bool {
+	return h.unchanged
+}
+
+// LastUpdate is when the cached body at h.path was last actually replaced,
+// i.e. the last non-304 fetch - not when Read was last called.
+func (h *HTTPVehicle) LastUpdate() time.Time {
+	return h.readMeta().FetchedAt
 }
 
 func (h *HTTPVehicle) Type() types.VehicleType {
@@ -50,6 +105,8 @@ func (h *HTTPVehicle) Path() string {
 }
 
 func (h *HTTPVehicle) Read() ([]byte, error) {
+	h.unchanged = false
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
 	defer cancel()
 
@@ -74,6 +131,14 @@ func (h *HTTPVehicle) Read() ([]byte, error) {
 
 	convert.SetUserAgent(req.Header)
 
+	meta := h.readMeta()
+	if meta.ETag != "" {
+		req.Header.Set("If-None-Match", meta.ETag)
+	}
+	if meta.LastModified != "" {
+		req.Header.Set("If-Modified-Since", meta.LastModified)
+	}
+
 	req = req.WithContext(ctx)
 
 	transport := &http.Transport{
@@ -100,14 +165,28 @@ func (h *HTTPVehicle) Read() ([]byte, error) {
 		_ = resp.Body.Close()
 	}()
 
+	if resp.StatusCode == http.StatusNotModified {
+		h.unchanged = true
+		return os.ReadFile(h.path)
+	}
+
 	buf, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	return removeComment(buf), nil
+	buf = removeComment(buf)
+	buf = convertSubscription(buf)
+
+	h.writeMeta(httpVehicleMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	})
+
+	return buf, nil
 }
 
 func NewHTTPVehicle(path string, url string, urlProxy bool, header http.Header) *HTTPVehicle {
-	return &HTTPVehicle{path, url, urlProxy, header}
+	return &HTTPVehicle{path: path, url: url, urlProxy: urlProxy, header: header}
 }
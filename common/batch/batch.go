@@ -0,0 +1,108 @@
+// Package batch runs a set of keyed, fallible jobs over a bounded worker
+// pool sharing one context, collecting each job's result (or the first
+// error, which cancels the rest) without the caller hand-rolling a
+// semaphore and WaitGroup at every call site.
+package batch
+
+import (
+	"context"
+	"sync"
+)
+
+// Option configures a Batch at construction time.
+type Option[T any] func(b *Batch[T])
+
+// WithConcurrencyNum bounds the number of jobs running at once. n <= 0
+// leaves the batch unbounded (every Go call starts immediately).
+func WithConcurrencyNum[T any](n int) Option[T] {
+	return func(b *Batch[T]) {
+		if n > 0 {
+			b.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// Batch runs jobs registered via Go against a shared context: the first
+// job to return an error cancels that context, so later/slower jobs can
+// bail out early instead of running to completion pointlessly.
+type Batch[T any] struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	mux      sync.Mutex
+	results  map[string]T
+	firstErr error
+}
+
+// New returns a Batch and the context jobs should use, which is canceled
+// once the first job fails or Wait returns.
+func New[T any](ctx context.Context, opts ...Option[T]) (*Batch[T], context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	b := &Batch[T]{
+		ctx:     ctx,
+		cancel:  cancel,
+		results: map[string]T{},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b, ctx
+}
+
+// Go starts fn under the batch's concurrency bound, recording its result
+// under key. fn isn't started at all if the batch's context is already
+// canceled by an earlier failure.
+func (b *Batch[T]) Go(key string, fn func() (T, error)) {
+	b.wg.Add(1)
+
+	go func() {
+		defer b.wg.Done()
+
+		if b.sem != nil {
+			select {
+			case b.sem <- struct{}{}:
+				defer func() { <-b.sem }()
+			case <-b.ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-b.ctx.Done():
+			return
+		default:
+		}
+
+		result, err := fn()
+
+		b.mux.Lock()
+		defer b.mux.Unlock()
+
+		if err != nil {
+			if b.firstErr == nil {
+				b.firstErr = err
+				b.cancel()
+			}
+			return
+		}
+		b.results[key] = result
+	}()
+}
+
+// Wait blocks until every started job has returned, then reports the
+// per-key results gathered so far and the first error encountered, if any.
+func (b *Batch[T]) Wait() (map[string]T, error) {
+	b.wg.Wait()
+	b.cancel()
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	results := make(map[string]T, len(b.results))
+	for k, v := range b.results {
+		results[k] = v
+	}
+	return results, b.firstErr
+}
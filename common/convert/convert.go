@@ -0,0 +1,43 @@
+// Package convert holds small helpers for blending in with ordinary browser
+// traffic - a random realistic User-Agent/Host for outbounds that fake an
+// HTTP request, and (in converter.go) parsing non-Clash subscription
+// payloads into the same proxy option maps the YAML config produces.
+package convert
+
+import (
+	"math/rand"
+	"net/http"
+)
+
+// userAgents are common desktop/mobile browser UAs; SetUserAgent picks
+// one at random so a plain (non-TLS) WS/HTTP outbound looks like
+// ordinary browser traffic to a passive observer or cache in the middle.
+var userAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+}
+
+// randHosts are plausible CDN-fronted hostnames for the fake Host header a
+// plain WS outbound sets when it isn't wrapped in TLS.
+var randHosts = []string{
+	"www.bing.com",
+	"www.microsoft.com",
+	"www.apple.com",
+	"www.amazon.com",
+}
+
+// RandHost returns a random, plausible-looking hostname.
+func RandHost() string {
+	return randHosts[rand.Intn(len(randHosts))]
+}
+
+// SetUserAgent sets header's User-Agent to a random common browser UA,
+// unless the caller already set one.
+func SetUserAgent(header http.Header) {
+	if header.Get("User-Agent") != "" {
+		return
+	}
+	header.Set("User-Agent", userAgents[rand.Intn(len(userAgents))])
+}
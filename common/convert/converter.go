@@ -0,0 +1,465 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DecodeBase64 tries std, then raw-std (no padding) base64 decoding of buf,
+// falling back to buf itself unmodified if neither succeeds - subscription
+// services are inconsistent about padding, and some don't base64-encode at
+// all.
+func DecodeBase64(buf []byte) []byte {
+	trimmed := strings.TrimSpace(string(buf))
+
+	if decoded, err := base64.StdEncoding.DecodeString(trimmed); err == nil {
+		return decoded
+	}
+	if decoded, err := base64.RawStdEncoding.DecodeString(trimmed); err == nil {
+		return decoded
+	}
+	return buf
+}
+
+// ConvertsV2Ray auto-detects a subscription payload's format - Clash YAML,
+// a single base64 blob, or a plaintext list - and parses every
+// vmess/vless/trojan/ss/ssr/hysteria URI line it finds into the same
+// map[string]any shape structure.Decoder builds outbound options from.
+// A line that fails to parse is skipped rather than failing the whole
+// subscription; ConvertsV2Ray returns every error alongside the proxies
+// that did parse.
+func ConvertsV2Ray(buf []byte) ([]map[string]any, []error) {
+	text := strings.TrimSpace(string(buf))
+	if looksLikeClashYAML(text) {
+		return nil, nil
+	}
+
+	if !strings.Contains(text, "://") {
+		if decoded := DecodeBase64(buf); len(decoded) > 0 {
+			text = strings.TrimSpace(string(decoded))
+		}
+	}
+
+	var (
+		proxies []map[string]any
+		errs    []error
+	)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		scheme, _, ok := strings.Cut(line, "://")
+		if !ok {
+			continue
+		}
+
+		parse, ok := schemeParsers[strings.ToLower(scheme)]
+		if !ok {
+			errs = append(errs, fmt.Errorf("convert: unsupported scheme %q", scheme))
+			continue
+		}
+
+		proxy, err := parse(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("convert: %s: %w", scheme, err))
+			continue
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	return proxies, errs
+}
+
+// looksLikeClashYAML is a cheap heuristic: Clash config/provider YAML has a
+// top-level "proxies:" key, which a base64 blob or URI list never produces
+// as plain text.
+func looksLikeClashYAML(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "proxies:") {
+			return true
+		}
+	}
+	return false
+}
+
+var schemeParsers = map[string]func(uri string) (map[string]any, error){
+	"vmess":    parseVmess,
+	"vless":    parseVless,
+	"trojan":   parseTrojan,
+	"ss":       parseShadowsocks,
+	"ssr":      parseShadowsocksR,
+	"hysteria": parseHysteria,
+}
+
+// vmessShareLink is the v2rayN-style JSON payload base64-encoded after the
+// vmess:// prefix.
+type vmessShareLink struct {
+	Ver  any    `json:"v"`
+	PS   string `json:"ps"`
+	Add  string `json:"add"`
+	Port any    `json:"port"`
+	ID   string `json:"id"`
+	Aid  any    `json:"aid"`
+	Net  string `json:"net"`
+	Type string `json:"type"`
+	Host string `json:"host"`
+	Path string `json:"path"`
+	TLS  string `json:"tls"`
+	SNI  string `json:"sni"`
+}
+
+func parseVmess(uri string) (map[string]any, error) {
+	_, body, _ := strings.Cut(uri, "://")
+
+	raw := DecodeBase64([]byte(body))
+	var link vmessShareLink
+	if err := json.Unmarshal(raw, &link); err != nil {
+		return nil, fmt.Errorf("decode vmess share link: %w", err)
+	}
+
+	port, err := toInt(link.Port)
+	if err != nil {
+		return nil, fmt.Errorf("port: %w", err)
+	}
+	aid, _ := toInt(link.Aid)
+
+	proxy := map[string]any{
+		"name":    nonEmpty(link.PS, link.Add),
+		"type":    "vmess",
+		"server":  link.Add,
+		"port":    port,
+		"uuid":    link.ID,
+		"alterId": aid,
+		"cipher":  "auto",
+		"udp":     true,
+	}
+
+	if link.Net != "" {
+		proxy["network"] = link.Net
+	}
+	if link.TLS == "tls" {
+		proxy["tls"] = true
+	}
+	if link.SNI != "" {
+		proxy["servername"] = link.SNI
+	}
+
+	switch link.Net {
+	case "ws":
+		proxy["ws-opts"] = map[string]any{
+			"path":    link.Path,
+			"headers": map[string]string{"Host": link.Host},
+		}
+	case "h2":
+		proxy["h2-opts"] = map[string]any{
+			"host": []string{link.Host},
+			"path": link.Path,
+		}
+	case "grpc":
+		proxy["grpc-opts"] = map[string]any{
+			"grpc-service-name": link.Path,
+		}
+	}
+
+	return proxy, nil
+}
+
+// parseVless parses `vless://uuid@host:port?query#name`.
+func parseVless(uri string) (map[string]any, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	host, port, err := hostPort(u)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	proxy := map[string]any{
+		"name":   nonEmpty(u.Fragment, host),
+		"type":   "vless",
+		"server": host,
+		"port":   port,
+		"uuid":   u.User.Username(),
+		"udp":    true,
+	}
+
+	if network := q.Get("type"); network != "" {
+		proxy["network"] = network
+	}
+	if flow := q.Get("flow"); flow != "" {
+		proxy["flow"] = flow
+	}
+	if sni := q.Get("sni"); sni != "" {
+		proxy["servername"] = sni
+	}
+	if fp := q.Get("fp"); fp != "" {
+		proxy["client-fingerprint"] = fp
+	}
+	if pbk := q.Get("pbk"); pbk != "" {
+		realityOpts := map[string]any{"public-key": pbk}
+		if sid := q.Get("sid"); sid != "" {
+			realityOpts["short-id"] = sid
+		}
+		proxy["reality-opts"] = realityOpts
+	}
+	applyTransportQuery(proxy, q)
+
+	return proxy, nil
+}
+
+// parseTrojan parses `trojan://password@host:port?query#name`.
+func parseTrojan(uri string) (map[string]any, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	host, port, err := hostPort(u)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	proxy := map[string]any{
+		"name":     nonEmpty(u.Fragment, host),
+		"type":     "trojan",
+		"server":   host,
+		"port":     port,
+		"password": u.User.Username(),
+		"udp":      true,
+	}
+
+	if sni := q.Get("sni"); sni != "" {
+		proxy["sni"] = sni
+	}
+	if network := q.Get("type"); network != "" {
+		proxy["network"] = network
+	}
+	applyTransportQuery(proxy, q)
+
+	return proxy, nil
+}
+
+// applyTransportQuery reads the ws/grpc transport parameters shared by the
+// vless:// and trojan:// URI schemes.
+func applyTransportQuery(proxy map[string]any, q url.Values) {
+	switch proxy["network"] {
+	case "ws":
+		wsOpts := map[string]any{"path": q.Get("path")}
+		if host := q.Get("host"); host != "" {
+			wsOpts["headers"] = map[string]string{"Host": host}
+		}
+		proxy["ws-opts"] = wsOpts
+	case "grpc":
+		proxy["grpc-opts"] = map[string]any{"grpc-service-name": q.Get("serviceName")}
+	}
+}
+
+// parseShadowsocks parses both SIP002 (`ss://base64(method:pass)@host:port`)
+// and the older fully-base64 (`ss://base64(method:pass@host:port)`) forms,
+// plus the SIP002 `plugin` query argument.
+func parseShadowsocks(uri string) (map[string]any, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	var method, password, host string
+	var portNum int
+
+	if u.User != nil && u.Host != "" {
+		// SIP002: ss://base64(method:pass)@host:port
+		if pw, ok := u.User.Password(); ok {
+			method, password = u.User.Username(), pw
+		} else if decoded := DecodeBase64([]byte(u.User.Username())); len(decoded) > 0 {
+			method, password, _ = strings.Cut(string(decoded), ":")
+		}
+		host, portNum, err = hostPort(u)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// legacy: ss://base64(method:pass@host:port)
+		_, body, _ := strings.Cut(uri, "://")
+		body, _, _ = strings.Cut(body, "#")
+		decoded := DecodeBase64([]byte(body))
+		cred, hostport, ok := strings.Cut(string(decoded), "@")
+		if !ok {
+			return nil, fmt.Errorf("malformed legacy ss:// link")
+		}
+		method, password, _ = strings.Cut(cred, ":")
+		var portStr string
+		host, portStr, err = net.SplitHostPort(hostport)
+		if err != nil {
+			return nil, err
+		}
+		portNum, err = strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("port: %w", err)
+		}
+	}
+
+	proxy := map[string]any{
+		"name":     nonEmpty(u.Fragment, host),
+		"type":     "ss",
+		"server":   host,
+		"port":     portNum,
+		"cipher":   method,
+		"password": password,
+		"udp":      true,
+	}
+
+	if plugin := u.Query().Get("plugin"); plugin != "" {
+		name, opts := parseSIP002Plugin(plugin)
+		proxy["plugin"] = name
+		proxy["plugin-opts"] = opts
+	}
+
+	return proxy, nil
+}
+
+// parseSIP002Plugin splits a SIP002 `plugin` query value
+// ("obfs-local;obfs=http;obfs-host=example.com") into its plugin name and
+// semicolon-separated option map.
+func parseSIP002Plugin(raw string) (string, map[string]any) {
+	parts := strings.Split(raw, ";")
+	opts := make(map[string]any, len(parts)-1)
+	for _, part := range parts[1:] {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			opts[k] = true
+			continue
+		}
+		opts[k] = v
+	}
+	return parts[0], opts
+}
+
+// parseShadowsocksR parses the fully-base64 SSR link format:
+// ssr://base64(host:port:protocol:method:obfs:base64(password)/?params).
+func parseShadowsocksR(uri string) (map[string]any, error) {
+	_, body, _ := strings.Cut(uri, "://")
+	decoded := string(DecodeBase64([]byte(body)))
+
+	main, query, _ := strings.Cut(decoded, "/?")
+	fields := strings.SplitN(main, ":", 6)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("malformed ssr:// link")
+	}
+
+	host, port, protocol, method, obfs, passB64 := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, fmt.Errorf("port: %w", err)
+	}
+
+	q, _ := url.ParseQuery(query)
+	name := string(DecodeBase64([]byte(q.Get("remarks"))))
+
+	proxy := map[string]any{
+		"name":     nonEmpty(name, host),
+		"type":     "ssr",
+		"server":   host,
+		"port":     portNum,
+		"cipher":   method,
+		"password": string(DecodeBase64([]byte(passB64))),
+		"protocol": protocol,
+		"obfs":     obfs,
+		"udp":      true,
+	}
+
+	if param := q.Get("protoparam"); param != "" {
+		proxy["protocol-param"] = string(DecodeBase64([]byte(param)))
+	}
+	if param := q.Get("obfsparam"); param != "" {
+		proxy["obfs-param"] = string(DecodeBase64([]byte(param)))
+	}
+
+	return proxy, nil
+}
+
+// parseHysteria parses `hysteria://host:port?query#name`.
+func parseHysteria(uri string) (map[string]any, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	host, port, err := hostPort(u)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	proxy := map[string]any{
+		"name":   nonEmpty(u.Fragment, host),
+		"type":   "hysteria",
+		"server": host,
+		"port":   port,
+	}
+
+	if auth := q.Get("auth"); auth != "" {
+		proxy["auth-str"] = auth
+	}
+	if sni := q.Get("peer"); sni != "" {
+		proxy["sni"] = sni
+	}
+	if alpn := q.Get("alpn"); alpn != "" {
+		proxy["alpn"] = strings.Split(alpn, ",")
+	}
+	if obfs := q.Get("obfs"); obfs != "" {
+		proxy["obfs"] = obfs
+	}
+	if q.Get("insecure") == "1" {
+		proxy["skip-cert-verify"] = true
+	}
+
+	return proxy, nil
+}
+
+func hostPort(u *url.URL) (host string, port int, err error) {
+	host = u.Hostname()
+	portStr := u.Port()
+	if host == "" || portStr == "" {
+		return "", 0, fmt.Errorf("missing host or port in %q", u.String())
+	}
+	port, err = strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("port: %w", err)
+	}
+	return host, port, nil
+}
+
+func toInt(v any) (int, error) {
+	switch t := v.(type) {
+	case float64:
+		return int(t), nil
+	case string:
+		if t == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(t)
+	case nil:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("unexpected numeric type %T", v)
+	}
+}
+
+func nonEmpty(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,90 @@
+// Package utils collects small generic helpers with no natural home of
+// their own.
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// IntRange is an inclusive [start, end] bound, as produced by parsing one
+// comma-separated segment of an IntRanges string.
+type IntRange[T integer] struct {
+	start T
+	end   T
+}
+
+// Contains reports whether v falls within the inclusive range.
+func (r IntRange[T]) Contains(v T) bool {
+	return v >= r.start && v <= r.end
+}
+
+// IntRanges is a set of inclusive integer ranges, e.g. parsed from the
+// `expected-status` config option ("200", "200-299", "200,204,301-302").
+type IntRanges[T integer] []IntRange[T]
+
+// Check reports whether v falls within any of the ranges. An empty
+// IntRanges matches nothing.
+func (rs IntRanges[T]) Check(v T) bool {
+	for _, r := range rs {
+		if r.Contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewIntRanges parses a comma-separated list of single values ("200") and
+// inclusive ranges ("200-299") into an IntRanges. An empty or all-whitespace
+// desc yields a nil (empty) IntRanges, not an error.
+func NewIntRanges[T integer](desc string) (IntRanges[T], error) {
+	desc = strings.TrimSpace(desc)
+	if desc == "" {
+		return nil, nil
+	}
+
+	var ranges IntRanges[T]
+	for _, part := range strings.Split(desc, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		start, end, isRange := strings.Cut(part, "-")
+		startN, err := parseInt[T](start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %w", part, err)
+		}
+
+		endN := startN
+		if isRange {
+			endN, err = parseInt[T](end)
+			if err != nil {
+				return nil, fmt.Errorf("invalid range %q: %w", part, err)
+			}
+		}
+
+		if endN < startN {
+			return nil, fmt.Errorf("invalid range %q: end before start", part)
+		}
+
+		ranges = append(ranges, IntRange[T]{start: startN, end: endN})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	return ranges, nil
+}
+
+func parseInt[T integer](s string) (T, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return T(n), nil
+}
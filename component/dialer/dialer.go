@@ -5,14 +5,17 @@ import (
 	"errors"
 	"net"
 	"net/netip"
+	"time"
 
 	"github.com/Dreamacro/clash/component/resolver"
 )
 
 func DialContext(ctx context.Context, network, address string, options ...Option) (net.Conn, error) {
 	opt := &option{
-		interfaceName: DefaultInterface.Load(),
-		routingMark:   int(DefaultRoutingMark.Load()),
+		interfaceName:          DefaultInterface.Load(),
+		routingMark:            int(DefaultRoutingMark.Load()),
+		resolutionDelay:        defaultResolutionDelay,
+		connectionAttemptDelay: defaultConnectionAttemptDelay,
 	}
 
 	for _, o := range DefaultOptions {
@@ -23,6 +26,16 @@ func DialContext(ctx context.Context, network, address string, options ...Option
 		o(opt)
 	}
 
+	switch network {
+	case "tcp", "udp":
+		switch opt.prefer {
+		case preferIPv4Only:
+			return DialContext(ctx, network+"4", address, options...)
+		case preferIPv6Only:
+			return DialContext(ctx, network+"6", address, options...)
+		}
+	}
+
 	switch network {
 	case "tcp4", "tcp6", "udp4", "udp6":
 		host, port, err := net.SplitHostPort(address)
@@ -99,87 +112,193 @@ func dialContext(ctx context.Context, network string, destination netip.Addr, po
 	if opt.routingMark != 0 {
 		bindMarkToDialer(opt.routingMark, dialer, network, destination)
 	}
+	if opt.policyRoute != nil {
+		if err := bindPolicyRouteToDialer(opt.policyRoute, dialer, network, destination); err != nil {
+			return nil, err
+		}
+	}
 
 	return dialer.DialContext(ctx, network, net.JoinHostPort(destination.String(), port))
 }
 
+// candidate is one address in the Happy Eyeballs v2 connection order.
+type candidate struct {
+	ip   netip.Addr
+	ipv6 bool
+}
+
 func dualStackDialContext(ctx context.Context, network, address string, opt *option) (net.Conn, error) {
 	host, port, err := net.SplitHostPort(address)
 	if err != nil {
 		return nil, err
 	}
 
-	returned := make(chan struct{})
-	defer close(returned)
-
-	type dialResult struct {
-		net.Conn
-		error
-		resolved bool
-		ipv6     bool
-		done     bool
-	}
-	results := make(chan dialResult)
-	var primary, fallback dialResult
-
-	startRacer := func(ctx context.Context, network, host string, direct bool, ipv6 bool) {
-		result := dialResult{ipv6: ipv6, done: true}
-		defer func() {
-			select {
-			case results <- result:
-			case <-returned:
-				if result.Conn != nil {
-					_ = result.Conn.Close()
-				}
-			}
-		}()
-
-		var ip netip.Addr
-		if ipv6 {
-			if !direct {
-				ip, result.error = resolver.ResolveIPv6ProxyServerHost(host)
-			} else {
-				ip, result.error = resolver.ResolveIPv6(host)
-			}
-		} else {
-			if !direct {
-				ip, result.error = resolver.ResolveIPv4ProxyServerHost(host)
-			} else {
-				ip, result.error = resolver.ResolveIPv4(host)
-			}
+	resolveV4 := func(ctx context.Context) (netip.Addr, error) {
+		if opt.direct {
+			return resolver.ResolveIPv4(host)
+		}
+		return resolver.ResolveIPv4ProxyServerHost(host)
+	}
+	resolveV6 := func(ctx context.Context) (netip.Addr, error) {
+		if opt.direct {
+			return resolver.ResolveIPv6(host)
 		}
-		if result.error != nil {
-			return
+		return resolver.ResolveIPv6ProxyServerHost(host)
+	}
+	dial := func(ctx context.Context, c candidate) (net.Conn, error) {
+		dialNetwork := network + "4"
+		if c.ipv6 {
+			dialNetwork = network + "6"
 		}
-		result.resolved = true
+		return dialContext(ctx, dialNetwork, c.ip, port, opt)
+	}
 
-		result.Conn, result.error = dialContext(ctx, network, ip, port, opt)
+	return happyEyeballsDial(ctx, opt, resolveV4, resolveV6, dial)
+}
+
+// happyEyeballsDial implements RFC 8305 Happy Eyeballs v2: resolveV4/
+// resolveV6 are raced, the preferred family's candidate is interleaved
+// first, and dial attempts down the resulting candidate list are staggered
+// by opt.connectionAttemptDelay. The first successful dial wins and cancels
+// the rest; if every attempt fails, the preferred family's error (or, if it
+// never resolved, the other family's) is returned.
+func happyEyeballsDial(
+	ctx context.Context,
+	opt *option,
+	resolveV4, resolveV6 func(ctx context.Context) (netip.Addr, error),
+	dial func(ctx context.Context, c candidate) (net.Conn, error),
+) (net.Conn, error) {
+	preferV6 := opt.prefer != preferIPv4
+
+	type resolveResult struct {
+		ip   netip.Addr
+		err  error
+		ipv6 bool
 	}
+	v4Ch := make(chan resolveResult, 1)
+	v6Ch := make(chan resolveResult, 1)
+	go func() {
+		ip, err := resolveV4(ctx)
+		v4Ch <- resolveResult{ip: ip, err: err}
+	}()
+	go func() {
+		ip, err := resolveV6(ctx)
+		v6Ch <- resolveResult{ip: ip, err: err, ipv6: true}
+	}()
 
-	go startRacer(ctx, network+"4", host, opt.direct, false)
-	go startRacer(ctx, network+"6", host, opt.direct, true)
+	preferredCh, otherCh := v6Ch, v4Ch
+	if !preferV6 {
+		preferredCh, otherCh = v4Ch, v6Ch
+	}
 
-	for res := range results {
-		if res.error == nil {
-			return res.Conn, nil
+	var preferred, other resolveResult
+	var otherResolved bool
+
+	select {
+	case preferred = <-preferredCh:
+		// Preferred family answered first: give the other family a short
+		// Resolution Delay to catch up so both can be interleaved.
+		select {
+		case other = <-otherCh:
+			otherResolved = true
+		case <-time.After(opt.resolutionDelay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
+	case other = <-otherCh:
+		otherResolved = true
+		preferred = <-preferredCh
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var candidates []candidate
+	var lastErr error
+	if preferred.err == nil {
+		candidates = append(candidates, candidate{ip: preferred.ip, ipv6: preferred.ipv6})
+	} else {
+		lastErr = preferred.err
+	}
+	if otherResolved && other.err == nil {
+		candidates = append(candidates, candidate{ip: other.ip, ipv6: other.ipv6})
+	} else if otherResolved && lastErr == nil {
+		lastErr = other.err
+	}
 
-		if !res.ipv6 {
-			primary = res
-		} else {
-			fallback = res
+	if len(candidates) == 0 {
+		if lastErr == nil {
+			lastErr = errors.New("dialer: no address resolved")
 		}
+		return nil, lastErr
+	}
 
-		if primary.done && fallback.done {
-			if primary.resolved {
-				return nil, primary.error
-			} else if fallback.resolved {
-				return nil, fallback.error
-			} else {
-				return nil, primary.error
+	// If the slower family hasn't answered within the Resolution Delay, we
+	// proceed with whichever candidates we already have rather than
+	// blocking the whole dial on it - RFC 8305 treats the delay as a best
+	// effort to interleave, not a hard requirement to wait for both.
+	return raceDials(ctx, candidates, opt.connectionAttemptDelay, dial, preferred.err)
+}
+
+// raceDials starts a dial to each candidate in order, staggered by delay,
+// returning the first success and cancelling the rest. If every attempt
+// fails, the last error from the first candidate's address family is
+// returned; preferredErr is returned only if no attempt was launched.
+func raceDials(
+	ctx context.Context,
+	candidates []candidate,
+	delay time.Duration,
+	dial func(ctx context.Context, c candidate) (net.Conn, error),
+	preferredErr error,
+) (net.Conn, error) {
+	dialCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type dialResult struct {
+		conn net.Conn
+		err  error
+		ipv6 bool
+	}
+	results := make(chan dialResult, len(candidates))
+
+	launched := 0
+	for i, c := range candidates {
+		c := c
+		if i > 0 {
+			select {
+			case <-time.After(delay):
+			case <-dialCtx.Done():
 			}
 		}
+		if dialCtx.Err() != nil {
+			break
+		}
+		launched++
+		go func() {
+			conn, err := dial(dialCtx, c)
+			results <- dialResult{conn: conn, err: err, ipv6: c.ipv6}
+		}()
 	}
 
-	return nil, errors.New("never touched")
+	var lastErr = preferredErr
+	var firstFamilyErr error
+	for i := 0; i < launched; i++ {
+		res := <-results
+		if res.err == nil {
+			cancel()
+			return res.conn, nil
+		}
+		lastErr = res.err
+		if res.ipv6 == candidates[0].ipv6 {
+			firstFamilyErr = res.err
+		}
+	}
+
+	if firstFamilyErr != nil {
+		return nil, firstFamilyErr
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("dialer: all candidates failed")
+	}
+	return nil, lastErr
 }
@@ -0,0 +1,109 @@
+package dialer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errUnreachable = errors.New("connection refused")
+
+func delayedResolver(d time.Duration, ip netip.Addr, err error) func(ctx context.Context) (netip.Addr, error) {
+	return func(ctx context.Context) (netip.Addr, error) {
+		select {
+		case <-time.After(d):
+			return ip, err
+		case <-ctx.Done():
+			return netip.Addr{}, ctx.Err()
+		}
+	}
+}
+
+type fakeConn struct {
+	net.Conn
+	ip netip.Addr
+}
+
+func dialReachable(reachable netip.Addr) func(ctx context.Context, c candidate) (net.Conn, error) {
+	return func(ctx context.Context, c candidate) (net.Conn, error) {
+		if c.ip != reachable {
+			return nil, errUnreachable
+		}
+		return &fakeConn{ip: c.ip}, nil
+	}
+}
+
+func testOption() *option {
+	return &option{
+		resolutionDelay:        20 * time.Millisecond,
+		connectionAttemptDelay: 20 * time.Millisecond,
+	}
+}
+
+func TestHappyEyeballsDial_PrefersIPv6WhenBothReachable(t *testing.T) {
+	v4 := netip.MustParseAddr("192.0.2.1")
+	v6 := netip.MustParseAddr("2001:db8::1")
+
+	resolveV4 := delayedResolver(0, v4, nil)
+	resolveV6 := delayedResolver(0, v6, nil)
+
+	dialAny := func(ctx context.Context, c candidate) (net.Conn, error) {
+		return &fakeConn{ip: c.ip}, nil
+	}
+
+	conn, err := happyEyeballsDial(context.Background(), testOption(), resolveV4, resolveV6, dialAny)
+	require.NoError(t, err)
+	require.Equal(t, v6, conn.(*fakeConn).ip)
+}
+
+func TestHappyEyeballsDial_FallsBackToIPv4WhenIPv6Unreachable(t *testing.T) {
+	v4 := netip.MustParseAddr("192.0.2.1")
+	v6 := netip.MustParseAddr("2001:db8::1")
+
+	resolveV4 := delayedResolver(0, v4, nil)
+	resolveV6 := delayedResolver(0, v6, nil)
+
+	conn, err := happyEyeballsDial(context.Background(), testOption(), resolveV4, resolveV6, dialReachable(v4))
+	require.NoError(t, err)
+	require.Equal(t, v4, conn.(*fakeConn).ip)
+}
+
+func TestHappyEyeballsDial_WaitsResolutionDelayForSlowerFamily(t *testing.T) {
+	v4 := netip.MustParseAddr("192.0.2.1")
+	v6 := netip.MustParseAddr("2001:db8::1")
+
+	// AAAA answers immediately, A answers just inside the resolution delay.
+	resolveV4 := delayedResolver(5*time.Millisecond, v4, nil)
+	resolveV6 := delayedResolver(0, v6, nil)
+
+	conn, err := happyEyeballsDial(context.Background(), testOption(), resolveV4, resolveV6, dialReachable(v4))
+	require.NoError(t, err)
+	require.Equal(t, v4, conn.(*fakeConn).ip)
+}
+
+func TestHappyEyeballsDial_ReturnsPreferredErrorWhenAllUnreachable(t *testing.T) {
+	v4 := netip.MustParseAddr("192.0.2.1")
+	v6 := netip.MustParseAddr("2001:db8::1")
+
+	resolveV4 := delayedResolver(0, v4, nil)
+	resolveV6 := delayedResolver(0, v6, nil)
+
+	_, err := happyEyeballsDial(context.Background(), testOption(), resolveV4, resolveV6, dialReachable(netip.MustParseAddr("192.0.2.2")))
+	require.ErrorIs(t, err, errUnreachable)
+}
+
+func TestHappyEyeballsDial_FallsBackWhenIPv6ResolveFails(t *testing.T) {
+	v4 := netip.MustParseAddr("192.0.2.1")
+
+	resolveV4 := delayedResolver(0, v4, nil)
+	resolveV6 := delayedResolver(0, netip.Addr{}, errors.New("no AAAA record"))
+
+	conn, err := happyEyeballsDial(context.Background(), testOption(), resolveV4, resolveV6, dialReachable(v4))
+	require.NoError(t, err)
+	require.Equal(t, v4, conn.(*fakeConn).ip)
+}
@@ -0,0 +1,125 @@
+package dialer
+
+import (
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+var (
+	DefaultOptions     []Option
+	DefaultInterface   = atomic.NewString("")
+	DefaultRoutingMark = atomic.NewInt32(0)
+)
+
+const (
+	// defaultResolutionDelay is RFC 8305's recommended "Resolution Delay":
+	// if the preferred family's answer arrives first, wait this long for
+	// the other family before giving up on interleaving them.
+	defaultResolutionDelay = 50 * time.Millisecond
+
+	// defaultConnectionAttemptDelay staggers successive connection
+	// attempts down the candidate list, per RFC 8305's "Connection
+	// Attempt Delay".
+	defaultConnectionAttemptDelay = 250 * time.Millisecond
+)
+
+type option struct {
+	interfaceName          string
+	addrReuse              bool
+	routingMark            int
+	direct                 bool
+	prefer                 int
+	resolutionDelay        time.Duration
+	connectionAttemptDelay time.Duration
+	policyRoute            *PolicyTable
+}
+
+type Option func(opt *option)
+
+func WithInterface(name string) Option {
+	return func(opt *option) {
+		opt.interfaceName = name
+	}
+}
+
+func WithAddrReuse(reuse bool) Option {
+	return func(opt *option) {
+		opt.addrReuse = reuse
+	}
+}
+
+func WithRoutingMark(mark int) Option {
+	return func(opt *option) {
+		opt.routingMark = mark
+	}
+}
+
+func WithDirect() Option {
+	return func(opt *option) {
+		opt.direct = true
+	}
+}
+
+// WithPreferIPv4 makes DialContext resolve and race with an IPv4-first,
+// fall-back-to-IPv6 tie-break, matching constant.DNSPreferIPv4.
+func WithPreferIPv4() Option {
+	return func(opt *option) {
+		opt.prefer = preferIPv4
+	}
+}
+
+// WithPreferIPv6 is WithPreferIPv4's IPv6-first counterpart.
+func WithPreferIPv6() Option {
+	return func(opt *option) {
+		opt.prefer = preferIPv6
+	}
+}
+
+// WithOnlySingleStack pins DialContext to a single address family, skipping
+// the dual-stack race entirely. v4 selects IPv4-only, otherwise IPv6-only.
+func WithOnlySingleStack(v4 bool) Option {
+	return func(opt *option) {
+		if v4 {
+			opt.prefer = preferIPv4Only
+		} else {
+			opt.prefer = preferIPv6Only
+		}
+	}
+}
+
+// WithResolutionDelay overrides the Happy Eyeballs v2 Resolution Delay used
+// by the dual-stack dialer (default 50ms).
+func WithResolutionDelay(d time.Duration) Option {
+	return func(opt *option) {
+		opt.resolutionDelay = d
+	}
+}
+
+// WithConnectionAttemptDelay overrides the Happy Eyeballs v2 Connection
+// Attempt Delay used to stagger successive dial attempts (default 250ms).
+func WithConnectionAttemptDelay(d time.Duration) Option {
+	return func(opt *option) {
+		opt.connectionAttemptDelay = d
+	}
+}
+
+// WithPolicyRoute makes dialContext look the destination up in rt and, on a
+// match, bind the dialer's LocalAddr to an address of that interface via
+// net.InterfaceByName, so it works even where SO_MARK/IP_BOUND_IF aren't
+// available. This is applied on top of any opt.interfaceName binding, not
+// instead of it. ListenPacket does not consult rt. A nil rt disables policy
+// routing.
+func WithPolicyRoute(rt *PolicyTable) Option {
+	return func(opt *option) {
+		opt.policyRoute = rt
+	}
+}
+
+const (
+	preferDual = iota
+	preferIPv4
+	preferIPv6
+	preferIPv4Only
+	preferIPv6Only
+)
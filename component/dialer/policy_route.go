@@ -0,0 +1,94 @@
+package dialer
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/Dreamacro/clash/component/trie"
+)
+
+// PolicyTable maps destination CIDRs to an outbound interface name, so a
+// source interface can be picked per-destination the same way SO_MARK-based
+// policy routing works on Linux, without needing root or eBPF. Geoip/geosite
+// rules resolve to CIDRs (or are resolved to an IP before dialing) and are
+// inserted the same way as any other entry.
+type PolicyTable struct {
+	rules *trie.IpCidrTrie[string]
+}
+
+// NewPolicyTable returns an empty PolicyTable ready for Insert calls.
+func NewPolicyTable() *PolicyTable {
+	return &PolicyTable{rules: trie.NewIpCidrTrie[string]()}
+}
+
+// Insert associates cidr with the interface that should be used to dial
+// destinations inside it. A more specific cidr takes priority over a less
+// specific one that also contains it.
+func (t *PolicyTable) Insert(cidr netip.Prefix, ifaceName string) {
+	t.rules.Insert(cidr, ifaceName)
+}
+
+// Match returns the interface name whose CIDR most specifically contains
+// destination, if any rule matches.
+func (t *PolicyTable) Match(destination netip.Addr) (string, bool) {
+	if t == nil || t.rules == nil {
+		return "", false
+	}
+	return t.rules.Search(destination)
+}
+
+// bindPolicyRouteToDialer looks destination up in rt and, on a match, binds
+// dialer's local address to that interface via net.InterfaceByName, the
+// portable equivalent of bindIfaceToDialer for platforms (or builds) where
+// the SO_MARK/IP_BOUND_IF hooks aren't available.
+func bindPolicyRouteToDialer(rt *PolicyTable, dialer *net.Dialer, network string, destination netip.Addr) error {
+	ifaceName, ok := rt.Match(destination)
+	if !ok {
+		return nil
+	}
+
+	addr, err := interfaceLocalAddr(ifaceName, network)
+	if err != nil {
+		return fmt.Errorf("policy route: %s: %w", ifaceName, err)
+	}
+
+	dialer.LocalAddr = addr
+	return nil
+}
+
+// interfaceLocalAddr picks an address belonging to ifaceName suitable as
+// net.Dialer.LocalAddr for network ("tcp4"/"tcp6"/"udp4"/"udp6").
+func interfaceLocalAddr(ifaceName, network string) (net.Addr, error) {
+	ifaceObj, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := ifaceObj.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	wantV4 := network == "tcp4" || network == "udp4"
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip, ok := netip.AddrFromSlice(ipNet.IP)
+		if !ok {
+			continue
+		}
+		ip = ip.Unmap()
+		if ip.Is4() != wantV4 {
+			continue
+		}
+		if network[:3] == "tcp" {
+			return &net.TCPAddr{IP: ip.AsSlice()}, nil
+		}
+		return &net.UDPAddr{IP: ip.AsSlice()}, nil
+	}
+
+	return nil, fmt.Errorf("no usable address on interface %s for %s", ifaceName, network)
+}
@@ -0,0 +1,90 @@
+// Package mmdb loads GeoIP data from a MaxMind GeoLite2/GeoIP2 Country
+// database, as an alternative to the v2ray-format `standard` loader.
+package mmdb
+
+import (
+	"fmt"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/Dreamacro/clash/component/geodata"
+	"github.com/Dreamacro/clash/component/geodata/router"
+	C "github.com/Dreamacro/clash/constant"
+)
+
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+type mmdbLoader struct{}
+
+// LoadIP walks every network in filename's MMDB and keeps the ones whose
+// ISO country code matches country, converting each into a router.CIDR the
+// same way the `standard` v2ray-format loader does.
+func (mmdbLoader) LoadIP(filename, country string) ([]*router.CIDR, error) {
+	db, err := maxminddb.Open(C.Path.Resolve(filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mmdb: %s, base error: %s", filename, err.Error())
+	}
+	defer db.Close()
+
+	var cidrs []*router.CIDR
+	networks := db.Networks(maxminddb.SkipAliasedNetworks)
+	for networks.Next() {
+		var record countryRecord
+		subnet, err := networks.Network(&record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mmdb network: %w", err)
+		}
+
+		if record.Country.ISOCode == "" || !equalFoldISO(record.Country.ISOCode, country) {
+			continue
+		}
+
+		ones, _ := subnet.Mask.Size()
+		cidrs = append(cidrs, &router.CIDR{
+			Ip:     subnet.IP,
+			Prefix: uint32(ones),
+		})
+	}
+	if err := networks.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate mmdb: %w", err)
+	}
+
+	if len(cidrs) == 0 {
+		return nil, fmt.Errorf("country not found in %s: %s", filename, country)
+	}
+	return cidrs, nil
+}
+
+// LoadSite isn't meaningful for an IP-only MaxMind database.
+func (mmdbLoader) LoadSite(filename, list string) ([]*router.Domain, error) {
+	return nil, fmt.Errorf("mmdb loader does not support geosite data: %s", filename)
+}
+
+func equalFoldISO(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		ca, cb := a[i], b[i]
+		if 'a' <= ca && ca <= 'z' {
+			ca -= 'a' - 'A'
+		}
+		if 'a' <= cb && cb <= 'z' {
+			cb -= 'a' - 'A'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	geodata.RegisterGeoDataLoaderImplementationCreator("mmdb", func() geodata.LoaderImplementation {
+		return mmdbLoader{}
+	})
+}
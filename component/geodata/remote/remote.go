@@ -0,0 +1,155 @@
+// Package remote loads v2ray-format geo .dat files from an HTTPS URL
+// instead of local disk, caching the response (and its ETag/Last-Modified
+// validators) so re-fetches are conditional.
+package remote
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Dreamacro/clash/component/geodata"
+	"github.com/Dreamacro/clash/component/geodata/router"
+	"github.com/Dreamacro/clash/component/geodata/standard"
+	C "github.com/Dreamacro/clash/constant"
+)
+
+const fetchTimeout = 30 * time.Second
+
+// cacheDir holds downloaded .dat files and their validators, alongside the
+// rest of clash's on-disk state.
+func cacheDir() string {
+	return C.Path.Resolve("geodata-cache")
+}
+
+func validatorPath(url string) string {
+	return filepath.Join(cacheDir(), sanitizeFilename(url)+".validators")
+}
+
+func dataPath(url string) string {
+	return filepath.Join(cacheDir(), sanitizeFilename(url)+".dat")
+}
+
+func sanitizeFilename(url string) string {
+	buf := []byte(url)
+	for i, b := range buf {
+		switch {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9':
+		default:
+			buf[i] = '_'
+		}
+	}
+	return string(buf)
+}
+
+// fetch downloads url into the on-disk cache, sending the cached ETag/
+// Last-Modified validators so an unchanged remote returns 304 and the
+// existing cached file is reused untouched.
+func fetch(url string) (string, error) {
+	if err := os.MkdirAll(cacheDir(), 0o755); err != nil {
+		return "", fmt.Errorf("remote geodata: create cache dir: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if etag, lastModified, ok := readValidators(url); ok {
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("remote geodata: fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	path := dataPath(url)
+
+	if resp.StatusCode == http.StatusNotModified {
+		if _, statErr := os.Stat(path); statErr == nil {
+			return path, nil
+		}
+		return "", fmt.Errorf("remote geodata: %s: got 304 but no cached file", url)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("remote geodata: %s: unexpected status %d", url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		return "", fmt.Errorf("remote geodata: write cache: %w", err)
+	}
+
+	writeValidators(url, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"))
+	return path, nil
+}
+
+func readValidators(url string) (etag, lastModified string, ok bool) {
+	raw, err := os.ReadFile(validatorPath(url))
+	if err != nil {
+		return "", "", false
+	}
+
+	lines := splitLines(string(raw))
+	if len(lines) != 2 {
+		return "", "", false
+	}
+	return lines[0], lines[1], true
+}
+
+func writeValidators(url, etag, lastModified string) {
+	_ = os.WriteFile(validatorPath(url), []byte(etag+"\n"+lastModified), 0o644)
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			lines = append(lines, s[start:i])
+			start = i + 1
+		}
+	}
+	lines = append(lines, s[start:])
+	return lines
+}
+
+type remoteLoader struct{}
+
+func (remoteLoader) LoadIP(url, country string) ([]*router.CIDR, error) {
+	path, err := fetch(url)
+	if err != nil {
+		return nil, err
+	}
+	return standard.LoadIPFromFile(path, country)
+}
+
+func (remoteLoader) LoadSite(url, list string) ([]*router.Domain, error) {
+	path, err := fetch(url)
+	if err != nil {
+		return nil, err
+	}
+	return standard.LoadSiteFromFile(path, list)
+}
+
+func init() {
+	geodata.RegisterGeoDataLoaderImplementationCreator("dat-remote", func() geodata.LoaderImplementation {
+		return remoteLoader{}
+	})
+}
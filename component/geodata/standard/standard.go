@@ -34,6 +34,18 @@ func loadIP(filename, country string) ([]*router.CIDR, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %s, base error: %s", filename, err.Error())
 	}
+	return parseIP(geoipBytes, filename, country)
+}
+
+func loadSite(filename, list string) ([]*router.Domain, error) {
+	geositeBytes, err := ReadAsset(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %s, base error: %s", filename, err.Error())
+	}
+	return parseSite(geositeBytes, filename, list)
+}
+
+func parseIP(geoipBytes []byte, filename, country string) ([]*router.CIDR, error) {
 	var geoipList router.GeoIPList
 	if err := proto.Unmarshal(geoipBytes, &geoipList); err != nil {
 		return nil, err
@@ -48,11 +60,7 @@ func loadIP(filename, country string) ([]*router.CIDR, error) {
 	return nil, fmt.Errorf("country not found in %s%s%s", filename, ": ", country)
 }
 
-func loadSite(filename, list string) ([]*router.Domain, error) {
-	geositeBytes, err := ReadAsset(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %s, base error: %s", filename, err.Error())
-	}
+func parseSite(geositeBytes []byte, filename, list string) ([]*router.Domain, error) {
 	var geositeList router.GeoSiteList
 	if err := proto.Unmarshal(geositeBytes, &geositeList); err != nil {
 		return nil, err
@@ -67,6 +75,26 @@ func loadSite(filename, list string) ([]*router.Domain, error) {
 	return nil, fmt.Errorf("list not found in %s%s%s", filename, ": ", list)
 }
 
+// LoadIPFromFile is LoadIP for a loader (e.g. remote's HTTPS fetch) that
+// already has the .dat at an absolute path rather than one resolved
+// relative to the asset directory.
+func LoadIPFromFile(path, country string) ([]*router.CIDR, error) {
+	geoipBytes, err := ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %s, base error: %s", path, err.Error())
+	}
+	return parseIP(geoipBytes, path, country)
+}
+
+// LoadSiteFromFile is LoadSite's LoadIPFromFile counterpart.
+func LoadSiteFromFile(path, list string) ([]*router.Domain, error) {
+	geositeBytes, err := ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %s, base error: %s", path, err.Error())
+	}
+	return parseSite(geositeBytes, path, list)
+}
+
 type standardLoader struct{}
 
 func (d standardLoader) LoadSite(filename, list string) ([]*router.Domain, error) {
@@ -0,0 +1,89 @@
+package geodata
+
+import (
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/Dreamacro/clash/log"
+)
+
+// ReloadSubscriber is notified after a watched geo data file changes on
+// disk, so it can rebuild whatever matcher it built from that file without
+// requiring a full Clash restart.
+type ReloadSubscriber func()
+
+var (
+	watchMux    sync.Mutex
+	watcher     *fsnotify.Watcher
+	watched     = map[string]struct{}{}
+	subscribers []ReloadSubscriber
+)
+
+// WatchFile arranges for path to be re-read and all registered
+// ReloadSubscribers notified whenever it changes on disk. Safe to call
+// more than once for the same path.
+func WatchFile(path string) error {
+	watchMux.Lock()
+	defer watchMux.Unlock()
+
+	if _, ok := watched[path]; ok {
+		return nil
+	}
+
+	if watcher == nil {
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		watcher = w
+		go runWatchLoop(watcher)
+	}
+
+	if err := watcher.Add(path); err != nil {
+		return err
+	}
+	watched[path] = struct{}{}
+	return nil
+}
+
+// Subscribe registers fn to be called after any watched file changes.
+func Subscribe(fn ReloadSubscriber) {
+	watchMux.Lock()
+	defer watchMux.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+func runWatchLoop(w *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			log.Infoln("[GeoData] %s changed, invalidating cache", event.Name)
+			onFileChanged()
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			log.Errorln("[GeoData] watcher error: %v", err)
+		}
+	}
+}
+
+func onFileChanged() {
+	ClearCache()
+
+	watchMux.Lock()
+	subs := make([]ReloadSubscriber, len(subscribers))
+	copy(subs, subscribers)
+	watchMux.Unlock()
+
+	for _, fn := range subs {
+		fn()
+	}
+}
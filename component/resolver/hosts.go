@@ -0,0 +1,144 @@
+package resolver
+
+import (
+	"context"
+	"math/rand"
+	"net/netip"
+
+	"github.com/Dreamacro/clash/component/trie"
+)
+
+// maxCNAMEHops bounds how many CNAME hops lookupHosts/LookupHostsIn will
+// follow before giving up, so a misconfigured or malicious hosts chain
+// can't spin the resolver in a loop.
+const maxCNAMEHops = 8
+
+// HostValue is a DefaultHosts entry: either one or more literal IPs (for
+// load-balancing across multiple A/AAAA records) or a CNAME pointing at
+// another name that should itself be looked up in DefaultHosts/DNS.
+//
+// It's stored in DefaultHosts as *HostValue because the IPs slice makes
+// HostValue itself non-comparable, and DomainTrie requires a comparable
+// value type.
+type HostValue struct {
+	IsDomain bool
+	Domain   string
+	IPs      []netip.Addr
+
+	// TTL overrides the default answer TTL a synthesized hosts response
+	// carries for this entry, in seconds. 0 means "use the caller's
+	// default".
+	TTL uint32
+}
+
+func NewIPHostValue(ips ...netip.Addr) *HostValue {
+	return &HostValue{IPs: ips}
+}
+
+// WithTTL sets a custom answer TTL on a freshly built HostValue and
+// returns it, for chaining onto NewIPHostValue/NewDomainHostValue at the
+// insert site.
+func (v *HostValue) WithTTL(ttl uint32) *HostValue {
+	v.TTL = ttl
+	return v
+}
+
+func NewDomainHostValue(domain string) *HostValue {
+	return &HostValue{IsDomain: true, Domain: domain}
+}
+
+// IPv4 returns one of v's IPv4 addresses, honouring ShouldRandomIP for the
+// choice among several.
+func (v *HostValue) IPv4(ctx contextChecker) (netip.Addr, bool) {
+	return v.pick(ctx, func(ip netip.Addr) bool { return ip.Is4() })
+}
+
+// IPv6 returns one of v's IPv6 addresses, honouring ShouldRandomIP for the
+// choice among several.
+func (v *HostValue) IPv6(ctx contextChecker) (netip.Addr, bool) {
+	return v.pick(ctx, func(ip netip.Addr) bool { return ip.Is6() })
+}
+
+// IP returns one of v's addresses regardless of family.
+func (v *HostValue) IP(ctx contextChecker) (netip.Addr, bool) {
+	return v.pick(ctx, func(netip.Addr) bool { return true })
+}
+
+func (v *HostValue) pick(ctx contextChecker, match func(netip.Addr) bool) (netip.Addr, bool) {
+	var candidates []netip.Addr
+	for _, ip := range v.IPs {
+		if match(ip) {
+			candidates = append(candidates, ip)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return netip.Addr{}, false
+	}
+
+	index := 0
+	if len(candidates) > 1 && ctx.Value(firstIPKey) == nil {
+		index = rand.Intn(len(candidates))
+	}
+	return candidates[index], true
+}
+
+// contextChecker is the subset of context.Context that pick needs to make
+// the same first-IP check ShouldRandomIP makes; any context.Context
+// satisfies it.
+type contextChecker interface {
+	Value(key any) any
+}
+
+// lookupHosts resolves host against DefaultHosts, following CNAME entries
+// up to maxCNAMEHops deep.
+//
+// found reports whether host has any hosts entry at all (IP or CNAME).
+// value is the terminal IP entry when one was reached; if a CNAME chain
+// ends without ever hitting an IP entry, value is nil and domain holds
+// the last CNAME target, which the caller should resolve through DNS.
+func lookupHosts(host string) (value *HostValue, domain string, found bool) {
+	return LookupHostsIn(DefaultHosts, host)
+}
+
+// LookupHostsIn applies lookupHosts' CNAME-following logic against an
+// arbitrary hosts tree instead of the package-level DefaultHosts, for a
+// caller (such as a dns.Resolver configured with its own host map) that
+// holds its own *trie.DomainTrie[*HostValue].
+func LookupHostsIn(tree *trie.DomainTrie[*HostValue], host string) (value *HostValue, domain string, found bool) {
+	domain = host
+
+	for i := 0; i < maxCNAMEHops; i++ {
+		node := tree.Search(domain)
+		if node == nil {
+			return nil, domain, found
+		}
+
+		found = true
+		value = node.Data
+		if !value.IsDomain {
+			return value, domain, true
+		}
+
+		domain = value.Domain
+	}
+
+	return nil, domain, true
+}
+
+// LookupIPByHost is a convenience for callers (like tunnel's redir-host
+// path) that just want "is there a hosts IP for this exact name", without
+// following CNAME chains or caring about address family.
+func LookupIPByHost(host string) (netip.Addr, bool) {
+	if node := DefaultHosts.Search(host); node != nil {
+		return node.Data.IP(context.Background())
+	}
+	return netip.Addr{}, false
+}
+
+// InsertHostByIP records a sniffed SNI/Host as a single-IP hosts entry so
+// later lookups of the same domain in this session skip DNS and reuse the
+// already-connected destination IP.
+func InsertHostByIP(ip netip.Addr, domain string) {
+	_ = DefaultHosts.Insert(domain, NewIPHostValue(ip))
+}
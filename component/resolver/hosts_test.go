@@ -0,0 +1,69 @@
+package resolver
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dreamacro/clash/component/trie"
+)
+
+func TestHostValue_MixedFamily(t *testing.T) {
+	v4 := netip.MustParseAddr("1.2.3.4")
+	v6 := netip.MustParseAddr("::1")
+	value := NewIPHostValue(v4, v6)
+
+	ip4, ok := value.IPv4(context.Background())
+	require.True(t, ok)
+	require.Equal(t, v4, ip4)
+
+	ip6, ok := value.IPv6(context.Background())
+	require.True(t, ok)
+	require.Equal(t, v6, ip6)
+}
+
+func TestHostValue_MultiIPFirstCandidateWithoutRandom(t *testing.T) {
+	ips := []netip.Addr{
+		netip.MustParseAddr("1.1.1.1"),
+		netip.MustParseAddr("1.1.1.2"),
+		netip.MustParseAddr("1.1.1.3"),
+	}
+	value := NewIPHostValue(ips...)
+
+	ctx := context.WithValue(context.Background(), firstIPKey, struct{}{})
+	for i := 0; i < 10; i++ {
+		ip, ok := value.IPv4(ctx)
+		require.True(t, ok)
+		require.Equal(t, ips[0], ip) // ShouldRandomIP is false, always first candidate
+	}
+}
+
+func TestLookupHosts_CNAMEChain(t *testing.T) {
+	defer func() { DefaultHosts = trie.New[*HostValue]() }()
+
+	DefaultHosts = trie.New[*HostValue]()
+	require.NoError(t, DefaultHosts.Insert("a.example.com", NewDomainHostValue("b.example.com")))
+	require.NoError(t, DefaultHosts.Insert("b.example.com", NewDomainHostValue("c.example.com")))
+	ip := netip.MustParseAddr("10.0.0.1")
+	require.NoError(t, DefaultHosts.Insert("c.example.com", NewIPHostValue(ip)))
+
+	value, domain, found := lookupHosts("a.example.com")
+	require.True(t, found)
+	require.Equal(t, "c.example.com", domain)
+	require.NotNil(t, value)
+	require.Equal(t, []netip.Addr{ip}, value.IPs)
+}
+
+func TestLookupHosts_CNAMELoop(t *testing.T) {
+	defer func() { DefaultHosts = trie.New[*HostValue]() }()
+
+	DefaultHosts = trie.New[*HostValue]()
+	require.NoError(t, DefaultHosts.Insert("loop-a.example.com", NewDomainHostValue("loop-b.example.com")))
+	require.NoError(t, DefaultHosts.Insert("loop-b.example.com", NewDomainHostValue("loop-a.example.com")))
+
+	value, _, found := lookupHosts("loop-a.example.com")
+	require.True(t, found)
+	require.Nil(t, value) // hop cap reached without ever finding an IP
+}
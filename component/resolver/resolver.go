@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"net"
 	"net/netip"
+	"sync"
 	"time"
 
 	"github.com/Dreamacro/clash/common/nnip"
@@ -23,8 +24,10 @@ var (
 	// default value is true
 	DisableIPv6 = true
 
-	// DefaultHosts aim to resolve hosts
-	DefaultHosts = trie.New[netip.Addr]()
+	// DefaultHosts aim to resolve hosts, supporting both multi-IP entries
+	// and CNAME chains via HostValue. Stored as a pointer since HostValue
+	// holds a slice and so isn't itself comparable.
+	DefaultHosts = trie.New[*HostValue]()
 
 	// DefaultDNSTimeout defined the default dns request timeout
 	DefaultDNSTimeout = time.Second * 5
@@ -52,9 +55,13 @@ func ResolveIPv4(host string) (netip.Addr, error) {
 }
 
 func ResolveIPv4WithResolver(ctx context.Context, host string, r Resolver) (netip.Addr, error) {
-	if node := DefaultHosts.Search(host); node != nil {
-		if ip := node.Data; ip.Is4() {
-			return ip, nil
+	if value, domain, found := lookupHosts(host); found {
+		if value != nil {
+			if ip, ok := value.IPv4(ctx); ok {
+				return ip, nil
+			}
+		} else if domain != host {
+			host = domain
 		}
 	}
 
@@ -112,9 +119,13 @@ func ResolveIPv6WithResolver(ctx context.Context, host string, r Resolver) (neti
 		return netip.Addr{}, ErrIPv6Disabled
 	}
 
-	if node := DefaultHosts.Search(host); node != nil {
-		if ip := node.Data; ip.Is6() {
-			return ip, nil
+	if value, domain, found := lookupHosts(host); found {
+		if value != nil {
+			if ip, ok := value.IPv6(ctx); ok {
+				return ip, nil
+			}
+		} else if domain != host {
+			host = domain
 		}
 	}
 
@@ -163,8 +174,14 @@ func ResolveIPv6WithResolver(ctx context.Context, host string, r Resolver) (neti
 
 // ResolveIPWithResolver same as ResolveIP, but with a resolver
 func ResolveIPWithResolver(ctx context.Context, host string, r Resolver) (netip.Addr, error) {
-	if node := DefaultHosts.Search(host); node != nil {
-		return node.Data, nil
+	if value, domain, found := lookupHosts(host); found {
+		if value != nil {
+			if ip, ok := value.IP(ctx); ok {
+				return ip, nil
+			}
+		} else if domain != host {
+			host = domain
+		}
 	}
 
 	if r != nil {
@@ -258,3 +275,81 @@ func resolveIPv4(ctx context.Context, host string) (netip.Addr, error) {
 func ShouldRandomIP(ctx context.Context) bool {
 	return ctx.Value(firstIPKey) == nil
 }
+
+// DNSPrefer mirrors constant.DNSPrefer without importing the constant
+// package, which already depends on resolver indirectly through rule types.
+type DNSPrefer = int
+
+const (
+	PreferDual DNSPrefer = iota
+	PreferIPv4
+	PreferIPv6
+	PreferIPv4Only
+	PreferIPv6Only
+)
+
+// AllIPResult holds the concurrently resolved A and AAAA answers for a host.
+type AllIPResult struct {
+	IPv4    netip.Addr
+	IPv4Err error
+	IPv6    netip.Addr
+	IPv6Err error
+}
+
+// ResolveAllIP resolves both the A and AAAA records for host concurrently.
+func ResolveAllIP(ctx context.Context, host string) AllIPResult {
+	return ResolveAllIPWithResolver(ctx, host, DefaultResolver)
+}
+
+// ResolveAllIPWithResolver is ResolveAllIP, but with a resolver.
+func ResolveAllIPWithResolver(ctx context.Context, host string, r Resolver) AllIPResult {
+	var result AllIPResult
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		result.IPv4, result.IPv4Err = ResolveIPv4WithResolver(ctx, host, r)
+	}()
+	go func() {
+		defer wg.Done()
+		result.IPv6, result.IPv6Err = ResolveIPv6WithResolver(ctx, host, r)
+	}()
+
+	wg.Wait()
+	return result
+}
+
+// ResolveIPWithPrefer resolves host honouring the requested IP-version
+// preference: only-* returns an error if that family isn't available,
+// prefer-* returns the requested family first but falls back to the other
+// when resolving the requested family fails with any error, and dual
+// behaves like ResolveIPWithResolver.
+func ResolveIPWithPrefer(ctx context.Context, host string, prefer DNSPrefer, r Resolver) (netip.Addr, error) {
+	switch prefer {
+	case PreferIPv4Only:
+		return ResolveIPv4WithResolver(ctx, host, r)
+	case PreferIPv6Only:
+		return ResolveIPv6WithResolver(ctx, host, r)
+	case PreferIPv4, PreferIPv6:
+		result := ResolveAllIPWithResolver(ctx, host, r)
+		if prefer == PreferIPv4 {
+			if result.IPv4Err == nil {
+				return result.IPv4, nil
+			}
+			if result.IPv6Err == nil {
+				return result.IPv6, nil
+			}
+			return netip.Addr{}, result.IPv4Err
+		}
+		if result.IPv6Err == nil {
+			return result.IPv6, nil
+		}
+		if result.IPv4Err == nil {
+			return result.IPv4, nil
+		}
+		return netip.Addr{}, result.IPv6Err
+	default:
+		return ResolveIPWithResolver(ctx, host, r)
+	}
+}
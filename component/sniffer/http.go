@@ -0,0 +1,54 @@
+package sniffer
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// HTTPSniffer recovers the target host from the Host header of the first
+// HTTP/1.x request on a plaintext connection.
+type HTTPSniffer struct{}
+
+func (HTTPSniffer) SniffTCP(b []byte) (string, error) {
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(b)))
+	if err != nil {
+		return "", ErrNoClue
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.Header.Get("Host")
+	}
+	if host == "" {
+		return "", ErrNoClue
+	}
+
+	// strip an explicit port, Host may be "example.com:8080"
+	if idx := strings.LastIndex(host, ":"); idx != -1 && !strings.Contains(host[idx+1:], "]") {
+		if _, err := parsePort(host[idx+1:]); err == nil {
+			host = host[:idx]
+		}
+	}
+
+	return host, nil
+}
+
+func (HTTPSniffer) Protocol() string {
+	return "http"
+}
+
+func parsePort(s string) (int, error) {
+	n := 0
+	if s == "" {
+		return 0, ErrNoClue
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, ErrNoClue
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, nil
+}
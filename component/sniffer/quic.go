@@ -0,0 +1,278 @@
+package sniffer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// quicInitialSalt is the QUIC v1 (RFC 9001) salt used to derive the
+// Initial secrets from a connection's Destination Connection
+// ID. It is public by design - Initial packets are only obfuscated, not
+// confidential - which is exactly what lets a middlebox sniff the SNI.
+var quicInitialSalt = []byte{0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3, 0x4d, 0x17, 0x9a, 0xe6, 0xa4, 0xc8, 0x0c, 0xad, 0xcc, 0xbb, 0x7f, 0x0a}
+
+// QUICSniffer recovers the SNI from the ClientHello carried inside a QUIC
+// Initial packet's CRYPTO frame. It only handles QUIC v1 long-header
+// Initial packets; anything else is reported via ErrNoClue so the caller
+// can keep buffering or give up.
+type QUICSniffer struct{}
+
+func (QUICSniffer) Protocol() string {
+	return "quic"
+}
+
+// SniffTCP is named to satisfy the Sniffer interface; it is fed UDP
+// datagrams by the PacketConn wrapper, not a TCP stream.
+func (q QUICSniffer) SniffTCP(b []byte) (string, error) {
+	payload, err := decryptQUICInitial(b)
+	if err != nil {
+		return "", err
+	}
+
+	return sniFromClientHello(payload)
+}
+
+func decryptQUICInitial(b []byte) ([]byte, error) {
+	if len(b) < 7 || b[0]&0x80 == 0 || (b[0]&0x30)>>4 != 0 {
+		return nil, ErrNoClue // not a long-header Initial packet
+	}
+
+	version := binary.BigEndian.Uint32(b[1:5])
+	if version == 0 {
+		return nil, ErrNoClue // version negotiation packet
+	}
+
+	off := 5
+	dcidLen := int(b[off])
+	off++
+	if off+dcidLen > len(b) {
+		return nil, ErrNoClue
+	}
+	dcid := b[off : off+dcidLen]
+	off += dcidLen
+
+	scidLen := int(b[off])
+	off += 1 + scidLen
+	if off >= len(b) {
+		return nil, ErrNoClue
+	}
+
+	tokenLen, n := readVarint(b[off:])
+	if n == 0 {
+		return nil, ErrNoClue
+	}
+	off += n + int(tokenLen)
+	if off >= len(b) {
+		return nil, ErrNoClue
+	}
+
+	length, n := readVarint(b[off:])
+	off += n
+	if off+int(length) > len(b) {
+		return nil, ErrNoClue
+	}
+
+	clientSecret := deriveClientInitialSecret(dcid)
+	hp := hkdfExpandLabel(clientSecret, "quic hp", 16)
+	key := hkdfExpandLabel(clientSecret, "quic key", 16)
+	iv := hkdfExpandLabel(clientSecret, "quic iv", 12)
+
+	packet := append([]byte(nil), b[:off+int(length)]...)
+	return removeHeaderProtectionAndDecrypt(packet, off, hp, key, iv)
+}
+
+func deriveClientInitialSecret(dcid []byte) []byte {
+	initialSecret := hkdf.Extract(sha256.New, dcid, quicInitialSalt)
+	return hkdfExpandLabel(initialSecret, "client in", 32)
+}
+
+func hkdfExpandLabel(secret []byte, label string, length int) []byte {
+	info := make([]byte, 0, 2+1+len(label)+1)
+	info = append(info, byte(length>>8), byte(length))
+	info = append(info, byte(len(label)))
+	info = append(info, label...)
+	info = append(info, 0)
+
+	out := make([]byte, length)
+	r := hkdf.Expand(sha256.New, secret, info)
+	_, _ = r.Read(out)
+	return out
+}
+
+func removeHeaderProtectionAndDecrypt(packet []byte, payloadOffset int, hp, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(hp)
+	if err != nil {
+		return nil, err
+	}
+
+	if payloadOffset+4 > len(packet) {
+		return nil, ErrNoClue
+	}
+	sampleOffset := payloadOffset + 4
+	if sampleOffset+16 > len(packet) {
+		return nil, ErrNoClue
+	}
+	sample := packet[sampleOffset : sampleOffset+16]
+
+	mask := make([]byte, 16)
+	block.Encrypt(mask, sample)
+
+	if packet[0]&0x80 != 0 {
+		packet[0] ^= mask[0] & 0x0f
+	}
+
+	pnLen := int(packet[0]&0x03) + 1
+	for i := 0; i < pnLen; i++ {
+		packet[payloadOffset+i] ^= mask[1+i]
+	}
+
+	pn := uint64(0)
+	for i := 0; i < pnLen; i++ {
+		pn = pn<<8 | uint64(packet[payloadOffset+i])
+	}
+
+	nonce := append([]byte(nil), iv...)
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(pn >> (8 * i))
+	}
+
+	block2, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block2)
+	if err != nil {
+		return nil, err
+	}
+
+	headerLen := payloadOffset + pnLen
+	header := packet[:headerLen]
+	cipherText := packet[headerLen:]
+
+	plain, err := aead.Open(nil, nonce, cipherText, header)
+	if err != nil {
+		return nil, ErrNoClue
+	}
+
+	return extractCryptoFrame(plain)
+}
+
+// extractCryptoFrame pulls the first CRYPTO frame's payload out of a
+// decrypted Initial packet's frame stream, skipping PADDING/PING frames.
+func extractCryptoFrame(frames []byte) ([]byte, error) {
+	for len(frames) > 0 {
+		switch frames[0] {
+		case 0x00: // PADDING
+			frames = frames[1:]
+		case 0x01: // PING
+			frames = frames[1:]
+		case 0x06: // CRYPTO
+			frames = frames[1:]
+			offset, n := readVarint(frames)
+			if n == 0 {
+				return nil, ErrNoClue
+			}
+			frames = frames[n:]
+			length, n := readVarint(frames)
+			if n == 0 {
+				return nil, ErrNoClue
+			}
+			frames = frames[n:]
+			if int(offset) != 0 || int(length) > len(frames) {
+				return nil, ErrNoClue // SNI lives in the first CRYPTO fragment
+			}
+			return frames[:length], nil
+		default:
+			return nil, ErrNoClue
+		}
+	}
+	return nil, ErrNoClue
+}
+
+func readVarint(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+
+	prefix := b[0] >> 6
+	length := 1 << prefix
+	if len(b) < length {
+		return 0, 0
+	}
+
+	v := uint64(b[0] & 0x3f)
+	for i := 1; i < length; i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v, length
+}
+
+// sniFromClientHello walks a TLS ClientHello handshake message looking for
+// the server_name extension (type 0x0000).
+func sniFromClientHello(hs []byte) (string, error) {
+	if len(hs) < 4 || hs[0] != 0x01 {
+		return "", ErrNoClue // not a ClientHello handshake message
+	}
+	body := hs[4:]
+
+	if len(body) < 2+32 {
+		return "", ErrNoClue
+	}
+	pos := 2 + 32 // client_version + random
+
+	if pos >= len(body) {
+		return "", ErrNoClue
+	}
+	sessionIDLen := int(body[pos])
+	pos += 1 + sessionIDLen
+	if pos+2 > len(body) {
+		return "", ErrNoClue
+	}
+
+	cipherSuitesLen := int(binary.BigEndian.Uint16(body[pos:]))
+	pos += 2 + cipherSuitesLen
+	if pos >= len(body) {
+		return "", ErrNoClue
+	}
+
+	compressionLen := int(body[pos])
+	pos += 1 + compressionLen
+	if pos+2 > len(body) {
+		return "", ErrNoClue
+	}
+
+	extensionsLen := int(binary.BigEndian.Uint16(body[pos:]))
+	pos += 2
+	if pos+extensionsLen > len(body) {
+		return "", ErrNoClue
+	}
+	extensions := body[pos : pos+extensionsLen]
+
+	for len(extensions) >= 4 {
+		extType := binary.BigEndian.Uint16(extensions[0:2])
+		extLen := int(binary.BigEndian.Uint16(extensions[2:4]))
+		if 4+extLen > len(extensions) {
+			return "", ErrNoClue
+		}
+		extData := extensions[4 : 4+extLen]
+
+		if extType == 0x0000 { // server_name
+			if len(extData) < 5 {
+				return "", ErrNoClue
+			}
+			nameLen := int(binary.BigEndian.Uint16(extData[3:5]))
+			if 5+nameLen > len(extData) {
+				return "", ErrNoClue
+			}
+			return string(extData[5 : 5+nameLen]), nil
+		}
+
+		extensions = extensions[4+extLen:]
+	}
+
+	return "", ErrNoClue
+}
@@ -0,0 +1,121 @@
+package sniffer
+
+import (
+	"errors"
+
+	"github.com/Dreamacro/clash/component/trie"
+)
+
+// ErrNoClue is returned by a Sniffer when the buffered bytes don't contain
+// enough of the protocol's handshake to make a determination yet; callers
+// should keep buffering and retry rather than giving up.
+var ErrNoClue = errors.New("not enough data to sniff")
+
+// Sniffer inspects the first bytes of a stream or datagram and tries to
+// recover the SNI/Host the client is dialling, without terminating the
+// connection or mutating the bytes it's given.
+type Sniffer interface {
+	// SniffTCP parses a TCP-oriented byte stream (TLS ClientHello, an HTTP
+	// request, ...) and returns the sniffed domain.
+	SniffTCP(b []byte) (host string, err error)
+	// Protocol names the sniffer for logging and `force-domain`/`skip-domain`
+	// bookkeeping, e.g. "tls", "http", "quic".
+	Protocol() string
+}
+
+// Set is an ordered collection of sniffers tried in turn until one succeeds.
+type Set struct {
+	sniffers []Sniffer
+	ports    map[uint16]bool
+
+	// forceDomain and skipDomain refine ShouldSniff beyond the plain
+	// "no domain known yet" default: forceDomain re-sniffs even a
+	// connection that already resolved to one of these domains (useful
+	// when the domain came from a rewritten/low-trust source), skipDomain
+	// never sniffs one, regardless of the other rule.
+	forceDomain *trie.DomainTrie[bool]
+	skipDomain  *trie.DomainTrie[bool]
+
+	// overrideDestination lets a sniffed host replace metadata.Host even
+	// when the connection already carried a domain, so rule matching can
+	// re-run against whatever TLS/HTTP actually asked for.
+	overrideDestination bool
+}
+
+// NewSet builds a sniffer Set out of the given sniffers, restricted to the
+// provided destination ports. An empty ports set matches every port.
+// forceDomain and skipDomain are domain (suffix) lists as accepted by
+// trie.DomainTrie, and overrideDestination controls whether a sniffed host
+// is allowed to replace a domain the connection already carried.
+func NewSet(sniffers []Sniffer, ports []uint16, forceDomain, skipDomain []string, overrideDestination bool) *Set {
+	set := &Set{sniffers: sniffers, overrideDestination: overrideDestination}
+	if len(ports) > 0 {
+		set.ports = make(map[uint16]bool, len(ports))
+		for _, port := range ports {
+			set.ports[port] = true
+		}
+	}
+
+	if len(forceDomain) > 0 {
+		set.forceDomain = trie.New[bool]()
+		for _, domain := range forceDomain {
+			_ = set.forceDomain.Insert(domain, true)
+		}
+	}
+	if len(skipDomain) > 0 {
+		set.skipDomain = trie.New[bool]()
+		for _, domain := range skipDomain {
+			_ = set.skipDomain.Insert(domain, true)
+		}
+	}
+
+	return set
+}
+
+// SupportsPort reports whether the set should attempt to sniff this port.
+func (s *Set) SupportsPort(port uint16) bool {
+	if s == nil || len(s.ports) == 0 {
+		return true
+	}
+	return s.ports[port]
+}
+
+// ShouldSniff reports whether a connection currently known by host (empty
+// for a bare IP destination) should be sniffed: skip-domain always wins,
+// force-domain sniffs even though host is already known, and otherwise
+// sniffing only makes sense when no domain is known yet.
+func (s *Set) ShouldSniff(host string) bool {
+	if s == nil {
+		return false
+	}
+	if host != "" && s.skipDomain != nil && s.skipDomain.Search(host) != nil {
+		return false
+	}
+	if host == "" {
+		return true
+	}
+	return s.forceDomain != nil && s.forceDomain.Search(host) != nil
+}
+
+// OverrideDestination reports whether a sniffed host may replace a domain
+// the connection already carried.
+func (s *Set) OverrideDestination() bool {
+	return s != nil && s.overrideDestination
+}
+
+// Sniff runs every sniffer in the set over b and returns the first
+// successful (domain, protocol) pair.
+func (s *Set) Sniff(b []byte) (host string, protocol string, err error) {
+	if s == nil {
+		return "", "", ErrNoClue
+	}
+
+	for _, sniffer := range s.sniffers {
+		host, err = sniffer.SniffTCP(b)
+		if err == nil && host != "" {
+			return host, sniffer.Protocol(), nil
+		}
+	}
+
+	return "", "", ErrNoClue
+}
@@ -0,0 +1,30 @@
+package sniffer
+
+import (
+	"strings"
+
+	"github.com/Dreamacro/clash/common/snifer/tls"
+)
+
+// TLSSniffer recovers the SNI from a TLS ClientHello. It wraps the existing
+// common/snifer/tls parser so the tunnel/statistic sniffing wrapper and the
+// new pluggable sniffer set share one implementation.
+type TLSSniffer struct{}
+
+func (TLSSniffer) SniffTCP(b []byte) (string, error) {
+	header, err := tls.SniffTLS(b)
+	if err != nil {
+		return "", err
+	}
+
+	domain := header.Domain()
+	if strings.Index(domain, ".") <= 0 {
+		return "", ErrNoClue
+	}
+
+	return domain, nil
+}
+
+func (TLSSniffer) Protocol() string {
+	return "tls"
+}
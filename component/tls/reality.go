@@ -0,0 +1,172 @@
+// Package tls collects TLS-adjacent helpers shared by outbound transports:
+// REALITY anti-censorship handshakes and (later) uTLS fingerprinting.
+package tls
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/Dreamacro/clash/component/ntp"
+)
+
+var (
+	ErrRealityPublicKeyLength = errors.New("reality: public-key must decode to 32 bytes")
+	ErrRealityShortIDLength   = errors.New("reality: short-id must decode to at most 8 bytes")
+)
+
+// RealityOptions is the user-facing `reality-opts` outbound config: a
+// base64url X25519 public key and a hex short-ID, as published by the
+// REALITY server operator.
+type RealityOptions struct {
+	PublicKey  string `yaml:"public-key" json:"public-key"`
+	ShortID    string `yaml:"short-id" json:"short-id"`
+	ServerName string `yaml:"server-name,omitempty" json:"server-name,omitempty"`
+}
+
+// RealityConfig is the parsed, ready-to-use form of RealityOptions.
+type RealityConfig struct {
+	PublicKey  [32]byte
+	ShortID    []byte
+	ServerName string
+}
+
+// Parse decodes and validates o into a RealityConfig.
+func (o RealityOptions) Parse() (*RealityConfig, error) {
+	pub, err := base64.RawURLEncoding.DecodeString(o.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("reality: decode public-key: %w", err)
+	}
+	if len(pub) != 32 {
+		return nil, ErrRealityPublicKeyLength
+	}
+
+	var shortID []byte
+	if o.ShortID != "" {
+		shortID, err = hex.DecodeString(o.ShortID)
+		if err != nil {
+			return nil, fmt.Errorf("reality: decode short-id: %w", err)
+		}
+	}
+	if len(shortID) > 8 {
+		return nil, ErrRealityShortIDLength
+	}
+
+	cfg := &RealityConfig{ShortID: shortID, ServerName: o.ServerName}
+	copy(cfg.PublicKey[:], pub)
+	return cfg, nil
+}
+
+// realityAuthPayload is encrypted into the ClientHello session-ID: it lets
+// the server recognise a genuine REALITY client among the cover site's
+// ordinary TLS traffic, and reject-as-cover everything else.
+type realityAuthPayload struct {
+	shortID   []byte
+	timestamp int64
+}
+
+func (p realityAuthPayload) marshal() []byte {
+	buf := make([]byte, 16)
+	copy(buf, p.shortID)
+	binary.BigEndian.PutUint64(buf[8:], uint64(p.timestamp))
+	return buf
+}
+
+// NewClientSessionID derives the 32-byte ClientHello session-ID carrying
+// the encrypted REALITY auth payload for a handshake against cfg's server.
+//
+// It (a) generates an ephemeral X25519 key pair, (b) derives a shared
+// secret with the server's public key, (c) uses HKDF-SHA256 over that
+// secret to key an AES-GCM AEAD which seals {shortID, timestamp}. The
+// returned bytes are the 12-byte nonce followed by the first 20 bytes of
+// the sealed payload and tag; the ephemeral public key is not part of
+// them, and the ephemeral private key is returned separately.
+func NewClientSessionID(cfg *RealityConfig) (sessionID []byte, ephemeralPriv [32]byte, err error) {
+	if _, err = rand.Read(ephemeralPriv[:]); err != nil {
+		return nil, ephemeralPriv, err
+	}
+
+	shared, err := curve25519.X25519(ephemeralPriv[:], cfg.PublicKey[:])
+	if err != nil {
+		return nil, ephemeralPriv, err
+	}
+
+	aead, err := realityAEAD(shared)
+	if err != nil {
+		return nil, ephemeralPriv, err
+	}
+
+	// the server rejects an auth payload whose timestamp has drifted too
+	// far from its own clock, so this goes through ntp.Now() rather than
+	// time.Now() directly when NTP sync is configured
+	payload := realityAuthPayload{shortID: cfg.ShortID, timestamp: ntp.Now().Unix()}.marshal()
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, ephemeralPriv, err
+	}
+
+	sealed := aead.Seal(nil, nonce, payload, nil)
+
+	// 32 bytes total: TLS session-ID width. nonce (12) + first 20 bytes of
+	// the sealed payload+tag; this is a compact on-the-wire encoding, not
+	// a cryptographic requirement.
+	sessionID = make([]byte, 0, 32)
+	sessionID = append(sessionID, nonce...)
+	sessionID = append(sessionID, sealed[:20]...)
+	return sessionID, ephemeralPriv, nil
+}
+
+func realityAEAD(sharedSecret []byte) (cipher.AEAD, error) {
+	key := make([]byte, 32)
+	if _, err := hkdf.Expand(sha256.New, sharedSecret, []byte("REALITY")).Read(key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// errNeedsUTLS marks every REALITY dial path as unavailable: setting a
+// custom ClientHello session-ID isn't something crypto/tls exposes, only a
+// uTLS client can, and this tree has no uTLS dependency yet. A REALITY
+// server can only recognise a client through that session-ID, so without
+// it there is no way to perform the real handshake at all - falling back
+// to a plain cover-site TLS session would look like a working REALITY
+// connection to the caller while providing none of its anti-censorship
+// properties, which is worse than failing outright.
+var errNeedsUTLS = errors.New("reality: the REALITY handshake requires the uTLS client (client-fingerprint option), which isn't built into this binary")
+
+// DialReality always returns errNeedsUTLS; it opens no connection to sni.
+func DialReality(sni string, cfg *RealityConfig) (net.Conn, error) {
+	return nil, errNeedsUTLS
+}
+
+// DialRealityConn would upgrade conn to an authenticated REALITY TLS
+// session against cfg's real server. See errNeedsUTLS.
+func DialRealityConn(conn net.Conn, sni string, cfg *RealityConfig, pin *[32]byte) (net.Conn, error) {
+	return nil, errNeedsUTLS
+}
+
+// IsCoverSiteCertificate reports whether the leaf certificate's raw bytes
+// look like the expected cover site's (the "fake" path): REALITY servers
+// serve the real proxy certificate only to clients that authenticated via
+// NewClientSessionID; anyone else - including active probes - gets the
+// cover site's actual cert back unmodified. coverFingerprint is the
+// SHA-256 of the cover certificate, pinned out of band by the operator.
+func IsCoverSiteCertificate(leafDER []byte, coverFingerprint [32]byte) bool {
+	return sha256.Sum256(leafDER) == coverFingerprint
+}
@@ -0,0 +1,100 @@
+package trie
+
+import "net/netip"
+
+// ipCidrNode is one bit of an IpCidrTrie: left is the 0 branch, right the 1
+// branch, walked most-significant-bit first.
+type ipCidrNode[T comparable] struct {
+	children [2]*ipCidrNode[T]
+	data     T
+	hasData  bool
+}
+
+// IpCidrTrie maps CIDR prefixes to arbitrary data and resolves an address to
+// its most specific (longest-prefix) match, the same role DomainTrie plays
+// for domains.
+type IpCidrTrie[T comparable] struct {
+	root4 *ipCidrNode[T]
+	root6 *ipCidrNode[T]
+}
+
+// NewIpCidrTrie returns a new, empty IpCidrTrie.
+func NewIpCidrTrie[T comparable]() *IpCidrTrie[T] {
+	return &IpCidrTrie[T]{
+		root4: &ipCidrNode[T]{},
+		root6: &ipCidrNode[T]{},
+	}
+}
+
+func rootFor[T comparable](t *IpCidrTrie[T], is4 bool) *ipCidrNode[T] {
+	if is4 {
+		return t.root4
+	}
+	return t.root6
+}
+
+// Insert adds prefix to the trie, associating it with data. Inserting the
+// same prefix twice overwrites the previous data.
+func (t *IpCidrTrie[T]) Insert(prefix netip.Prefix, data T) {
+	prefix = prefix.Masked()
+	addr := prefix.Addr()
+	node := rootFor(t, addr.Is4())
+
+	bits := addr.As16()
+	offset := 0
+	if addr.Is4() {
+		offset = 12
+	}
+
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := bitAt(bits[:], offset, i)
+		if node.children[bit] == nil {
+			node.children[bit] = &ipCidrNode[T]{}
+		}
+		node = node.children[bit]
+	}
+
+	node.data = data
+	node.hasData = true
+}
+
+// Search returns the data of the most specific prefix containing addr, and
+// whether any prefix matched at all.
+func (t *IpCidrTrie[T]) Search(addr netip.Addr) (T, bool) {
+	node := rootFor(t, addr.Is4())
+
+	bits := addr.As16()
+	offset := 0
+	if addr.Is4() {
+		offset = 12
+	}
+
+	maxBits := 128
+	if addr.Is4() {
+		maxBits = 32
+	}
+
+	var best T
+	var matched bool
+	for i := 0; ; i++ {
+		if node.hasData {
+			best, matched = node.data, true
+		}
+		if i == maxBits {
+			break
+		}
+		bit := bitAt(bits[:], offset, i)
+		if node.children[bit] == nil {
+			break
+		}
+		node = node.children[bit]
+	}
+
+	return best, matched
+}
+
+func bitAt(b []byte, offset, i int) int {
+	byteIdx := offset + i/8
+	bitIdx := 7 - uint(i%8)
+	return int((b[byteIdx] >> bitIdx) & 1)
+}
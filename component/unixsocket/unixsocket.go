@@ -0,0 +1,131 @@
+// Package unixsocket lets an inbound's `listen:` value be a Unix domain
+// socket path (`unix:///var/run/clash-http.sock`) instead of a host:port,
+// for colocated containers and privileged-helper setups that want fs-perm
+// based access control instead of (or alongside) TCP.
+package unixsocket
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+const schemePrefix = "unix://"
+
+// IsUnixAddr reports whether addr is a `unix://` listen address rather than
+// a host:port.
+func IsUnixAddr(addr string) bool {
+	return strings.HasPrefix(addr, schemePrefix)
+}
+
+// Options are the optional `unix-mode`/`unix-owner` YAML fields alongside a
+// `unix://` listen address.
+type Options struct {
+	// Mode is the socket file's permission bits, e.g. 0o660. Zero leaves
+	// whatever umask-determined mode net.Listen produced.
+	Mode os.FileMode
+	// Owner is "user[:group]"; either half may be omitted to leave it
+	// unchanged. Empty leaves the socket owned by the running process.
+	Owner string
+}
+
+// Listen parses a `unix://` addr, removes any stale socket file left over
+// from an unclean shutdown, and binds a net.UnixListener with opts applied.
+func Listen(addr string, opts Options) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, schemePrefix)
+	if !ok {
+		return nil, fmt.Errorf("unixsocket: not a unix:// address: %s", addr)
+	}
+
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Mode != 0 {
+		if err := os.Chmod(path, opts.Mode); err != nil {
+			_ = l.Close()
+			return nil, fmt.Errorf("unixsocket: chmod %s: %w", path, err)
+		}
+	}
+
+	if opts.Owner != "" {
+		if err := chown(path, opts.Owner); err != nil {
+			_ = l.Close()
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// removeStaleSocket deletes a pre-existing socket file at path, the same
+// way a plain TCP listener reclaims a port a dead process left bound.
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("unixsocket: refusing to remove non-socket file: %s", path)
+	}
+	return os.Remove(path)
+}
+
+// chown applies an "user[:group]" owner string to path, resolving either
+// half that's present via os/user and leaving the other half unchanged.
+func chown(path, owner string) error {
+	uidStr, gidStr, hasGroup := strings.Cut(owner, ":")
+
+	uid := -1
+	if uidStr != "" {
+		u, err := lookupUID(uidStr)
+		if err != nil {
+			return fmt.Errorf("unixsocket: owner %q: %w", owner, err)
+		}
+		uid = u
+	}
+
+	gid := -1
+	if hasGroup && gidStr != "" {
+		g, err := lookupGID(gidStr)
+		if err != nil {
+			return fmt.Errorf("unixsocket: owner %q: %w", owner, err)
+		}
+		gid = g
+	}
+
+	return os.Chown(path, uid, gid)
+}
+
+func lookupUID(name string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(u.Uid)
+}
+
+func lookupGID(name string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(g.Gid)
+}
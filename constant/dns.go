@@ -0,0 +1,48 @@
+package constant
+
+// DNSPrefer is the per-outbound IP-version preference used when resolving
+// a destination host, mirroring the global resolver.DisableIPv6 switch but
+// selectable independently for each outbound.
+const (
+	DNSPreferDual DNSPrefer = iota
+	DNSPreferIPv4
+	DNSPreferIPv6
+	DNSPreferIPv4Only
+	DNSPreferIPv6Only
+)
+
+type DNSPrefer int
+
+func (d DNSPrefer) String() string {
+	switch d {
+	case DNSPreferDual:
+		return "dual"
+	case DNSPreferIPv4:
+		return "ipv4-prefer"
+	case DNSPreferIPv6:
+		return "ipv6-prefer"
+	case DNSPreferIPv4Only:
+		return "ipv4-only"
+	case DNSPreferIPv6Only:
+		return "ipv6-only"
+	default:
+		return "unknown"
+	}
+}
+
+// NewDNSPrefer parses the `ip-version` YAML field into a DNSPrefer, falling
+// back to DNSPreferDual for an empty or unrecognised value.
+func NewDNSPrefer(prefer string) DNSPrefer {
+	switch prefer {
+	case "ipv4-prefer":
+		return DNSPreferIPv4
+	case "ipv6-prefer":
+		return DNSPreferIPv6
+	case "ipv4-only":
+		return DNSPreferIPv4Only
+	case "ipv6-only":
+		return DNSPreferIPv6Only
+	default:
+		return DNSPreferDual
+	}
+}
@@ -17,6 +17,11 @@ const (
 	ProcessPath
 	Script
 	UserAgent
+	SubRules
+	PreferRules
+	InName
+	InType
+	RuleSet
 	MATCH
 )
 
@@ -50,6 +55,16 @@ func (rt RuleType) String() string {
 		return "Script"
 	case UserAgent:
 		return "UserAgent"
+	case SubRules:
+		return "SubRules"
+	case PreferRules:
+		return "PreferRules"
+	case InName:
+		return "InName"
+	case InType:
+		return "InType"
+	case RuleSet:
+		return "RuleSet"
 	case MATCH:
 		return "Match"
 	default:
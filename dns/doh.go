@@ -4,24 +4,43 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"time"
 
 	D "github.com/miekg/dns"
 
 	"github.com/Dreamacro/clash/component/dialer"
 	"github.com/Dreamacro/clash/component/resolver"
+	tlsC "github.com/Dreamacro/clash/component/tls"
+	"github.com/Dreamacro/clash/log"
 )
 
 const (
 	// dotMimeType is the DoH mimetype that should be used.
 	dotMimeType = "application/dns-message"
+
+	// dohRetryTimeout bounds each individual retry attempt below Exchange's
+	// overall context, so one slow/misbehaving upstream can't eat the
+	// whole retry budget on its own.
+	dohRetryTimeout = time.Second * 2
+	dohMaxRetries   = 2
 )
 
+// errNeedsHTTP3 marks H3 as requested but unavailable: this tree has no
+// HTTP/3 (quic-go) client yet, so newDoHClient falls back to HTTP/2 below
+// rather than silently ignoring the "h3" option.
+var errNeedsHTTP3 = errors.New("doh: http/3 transport requested but not built into this binary")
+
 type dohClient struct {
 	url          string
 	proxyAdapter string
+	useGET       bool
+	h3Fallback   bool
 	transport    *http.Transport
 }
 
@@ -34,26 +53,71 @@ func (dc *dohClient) ExchangeContext(ctx context.Context, m *D.Msg) (msg *D.Msg,
 	// In order to maximize cache friendliness, SHOULD use a DNS ID of 0 in every DNS request.
 	newM := *m
 	newM.Id = 0
-	req, err := dc.newRequest(&newM)
-	if err != nil {
-		return nil, err
+
+	for attempt := 0; ; attempt++ {
+		req, err2 := dc.newRequest(&newM)
+		if err2 != nil {
+			return nil, err2
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, dohRetryTimeout)
+		req = req.WithContext(reqCtx)
+		msg, err = dc.doRequest(req)
+		cancel()
+		if err == nil {
+			msg.Id = m.Id
+			return msg, nil
+		}
+
+		if attempt >= dohMaxRetries || !shouldRetryDoH(err) {
+			return nil, err
+		}
 	}
+}
 
-	req = req.WithContext(ctx)
-	msg, err = dc.doRequest(req)
+// shouldRetryDoH reports whether a failed DoH attempt is worth retrying,
+// i.e. a transient network error or an upstream 5xx rather than a
+// malformed request or response.
+func shouldRetryDoH(err error) bool {
 	if err == nil {
-		msg.Id = m.Id
+		return false
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
 	}
-	return
+	if se, ok := err.(*dohStatusError); ok {
+		return se.statusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+type dohStatusError struct {
+	statusCode int
 }
 
-// newRequest returns a new DoH request given a dns.Msg.
+func (e *dohStatusError) Error() string {
+	return fmt.Sprintf("doh server returned status code %d", e.statusCode)
+}
+
+// newRequest returns a new DoH request given a dns.Msg. GET requests
+// base64url-encode the packed query into the `dns` parameter, which is
+// more cache-friendly at intermediaries than POST; see RFC 8484 §4.1.
 func (dc *dohClient) newRequest(m *D.Msg) (*http.Request, error) {
 	buf, err := m.Pack()
 	if err != nil {
 		return nil, err
 	}
 
+	if dc.useGET {
+		q := base64.RawURLEncoding.EncodeToString(buf)
+		req, err := http.NewRequest(http.MethodGet, dc.url+"?dns="+q, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("accept", dotMimeType)
+		return req, nil
+	}
+
 	req, err := http.NewRequest(http.MethodPost, dc.url, bytes.NewReader(buf))
 	if err != nil {
 		return req, err
@@ -74,6 +138,10 @@ func (dc *dohClient) doRequest(req *http.Request) (msg *D.Msg, err error) {
 		_ = resp.Body.Close()
 	}()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, &dohStatusError{statusCode: resp.StatusCode}
+	}
+
 	buf, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -83,38 +151,78 @@ func (dc *dohClient) doRequest(req *http.Request) (msg *D.Msg, err error) {
 	return msg, err
 }
 
-func newDoHClient(url string, r *Resolver, proxyAdapter string) *dohClient {
+func newDoHClient(url string, r *Resolver, proxyAdapter string, useGET bool, useH3 bool, h3Fallback bool, clientFingerprint string) *dohClient {
+	if useH3 {
+		// TODO(chunk1-1): negotiate ALPN "h3"/"doq" over quic-go once that
+		// dependency lands; until then we can only honor the fallback.
+		if !h3Fallback {
+			log.Warnln("[DNS] doh %s: http/3 requested without fallback, but this build has no http/3 transport; continuing over http/2", url)
+		} else {
+			log.Debugln("[DNS] doh %s: http/3 requested, falling back to http/2: %s", url, errNeedsHTTP3)
+		}
+	}
+
+	dialPlain := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		ip, err := resolver.ResolveIPWithResolver(ctx, host, r)
+		if err != nil {
+			return nil, err
+		}
+
+		if proxyAdapter != "" {
+			var conn net.Conn
+			conn, err = dialContextWithProxyAdapter(ctx, proxyAdapter, "tcp", ip, port)
+			if err == errProxyNotFound {
+				options := []dialer.Option{dialer.WithInterface(proxyAdapter), dialer.WithRoutingMark(0)}
+				conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), port), options...)
+			}
+			return conn, err
+		}
+
+		return dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), port))
+	}
+
+	transport := &http.Transport{ForceAttemptHTTP2: true}
+	if clientFingerprint != "" {
+		// TLS goes through tlsC.DialWithFingerprint, which has no uTLS
+		// to build the hello with and always returns an error, so every
+		// query to a server with a fingerprint set fails.
+		transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialPlain(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return tlsC.DialWithFingerprint(ctx, conn, clientFingerprint, &tls.Config{
+				ServerName: addrHost(addr),
+				NextProtos: []string{"dns"},
+			})
+		}
+	} else {
+		transport.DialContext = dialPlain
+		transport.TLSClientConfig = &tls.Config{
+			NextProtos: []string{"dns"},
+		}
+	}
+
 	return &dohClient{
 		url:          url,
 		proxyAdapter: proxyAdapter,
-		transport: &http.Transport{
-			ForceAttemptHTTP2: true,
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				host, port, err := net.SplitHostPort(addr)
-				if err != nil {
-					return nil, err
-				}
-
-				ip, err := resolver.ResolveIPWithResolver(ctx, host, r)
-				if err != nil {
-					return nil, err
-				}
-
-				if proxyAdapter != "" {
-					var conn net.Conn
-					conn, err = dialContextWithProxyAdapter(ctx, proxyAdapter, "tcp", ip, port)
-					if err == errProxyNotFound {
-						options := []dialer.Option{dialer.WithInterface(proxyAdapter), dialer.WithRoutingMark(0)}
-						conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), port), options...)
-					}
-					return conn, err
-				}
-
-				return dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), port))
-			},
-			TLSClientConfig: &tls.Config{
-				NextProtos: []string{"dns"},
-			},
-		},
+		useGET:       useGET,
+		h3Fallback:   h3Fallback,
+		transport:    transport,
+	}
+}
+
+// addrHost strips the port from a "host:port" address for use as a TLS
+// ServerName, tolerating an unparsable addr by returning it unchanged.
+func addrHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
 	}
+	return host
 }
@@ -0,0 +1,187 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	D "github.com/miekg/dns"
+
+	"github.com/Dreamacro/clash/component/dialer"
+	"github.com/Dreamacro/clash/component/resolver"
+)
+
+// doqIPCacheTTL bounds how long doqClient trusts its cached upstream
+// address - long enough to spare most queries a fresh bootstrap
+// resolution, short enough that an upstream IP rotation is picked up
+// without restarting Clash.
+const doqIPCacheTTL = 5 * time.Minute
+
+// errNeedsQUIC marks DoQ as configured but unusable: this tree has no QUIC
+// transport (quic-go) yet, so doqClient can frame RFC 9250 queries but
+// cannot actually open the stream. Wiring up quic-go is tracked alongside
+// the DoH3 transport in doh.go.
+var errNeedsQUIC = errors.New("doq: quic transport not built into this binary")
+
+// doqClient is a DNS-over-QUIC (RFC 9250) nameserver client. It frames each
+// query with the RFC 9250 §4.2 length prefix and the DNS ID forced to 0,
+// but roundTrip has no QUIC transport to send it on, so every exchange
+// fails with errNeedsQUIC.
+type doqClient struct {
+	addr         string
+	r            *Resolver
+	iface        string
+	proxyAdapter string
+
+	// ipMu/ip/ipExpire cache the upstream's resolved address across
+	// queries, the same reuse client already gets for plain DNS, bounded
+	// by doqIPCacheTTL so an upstream IP rotation is eventually picked up
+	// even with no dial ever failing against the stale address. Once
+	// quic-go is wired in, roundTrip can additionally keep the QUIC
+	// session itself alive across queries (and attempt 0-RTT on it)
+	// instead of redialing.
+	ipMu     sync.Mutex
+	ip       netip.Addr
+	ipExpire time.Time
+}
+
+func (dc *doqClient) Exchange(m *D.Msg) (msg *D.Msg, err error) {
+	return dc.ExchangeContext(context.Background(), m)
+}
+
+func (dc *doqClient) ExchangeContext(ctx context.Context, m *D.Msg) (msg *D.Msg, err error) {
+	newM := *m
+	newM.Id = 0
+
+	framed, err := frameDoQQuery(&newM)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := dc.roundTrip(ctx, framed)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err = unframeDoQResponse(raw)
+	if err != nil {
+		return nil, err
+	}
+	msg.Id = m.Id
+	return msg, nil
+}
+
+// roundTrip resolves dc.addr (through the cached address) the same way the
+// plain DNS client resolves its upstream host and, with a proxy adapter
+// set, dials it over UDP and discards the connection. It sends nothing and
+// always returns an error: a dial or resolution error, or errNeedsQUIC.
+func (dc *doqClient) roundTrip(ctx context.Context, framed []byte) ([]byte, error) {
+	host, port, err := net.SplitHostPort(dc.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ip, err := dc.resolveIP(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []dialer.Option{}
+	if dc.iface != "" {
+		options = append(options, dialer.WithInterface(dc.iface))
+	}
+
+	if dc.proxyAdapter != "" {
+		_, err = dialContextWithProxyAdapter(ctx, dc.proxyAdapter, "udp", ip, port, options...)
+		if err != nil && err != errProxyNotFound {
+			// The cached address may simply be stale (upstream IP
+			// rotation, transient resolution failure survived into the
+			// cache) rather than the proxy itself being down - drop it so
+			// the next query re-resolves instead of retrying the same
+			// dead IP for the rest of doqIPCacheTTL.
+			dc.invalidateIP()
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %w", dc.addr, errNeedsQUIC)
+}
+
+// resolveIP resolves and caches host's address for up to doqIPCacheTTL,
+// mirroring the reuse client already gets for plain DNS upstreams so
+// repeated queries don't each pay a fresh bootstrap resolution, while still
+// picking up an upstream IP rotation once the cache expires.
+func (dc *doqClient) resolveIP(ctx context.Context, host string) (netip.Addr, error) {
+	dc.ipMu.Lock()
+	cached, expire := dc.ip, dc.ipExpire
+	dc.ipMu.Unlock()
+	if cached.IsValid() && time.Now().Before(expire) {
+		return cached, nil
+	}
+
+	ip, err := resolver.ResolveIPWithResolver(ctx, host, dc.r)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	dc.ipMu.Lock()
+	dc.ip = ip
+	dc.ipExpire = time.Now().Add(doqIPCacheTTL)
+	dc.ipMu.Unlock()
+	return ip, nil
+}
+
+// invalidateIP drops the cached upstream address so the next query
+// re-resolves it instead of retrying a dial that just failed.
+func (dc *doqClient) invalidateIP() {
+	dc.ipMu.Lock()
+	dc.ip = netip.Addr{}
+	dc.ipMu.Unlock()
+}
+
+// frameDoQQuery packs m and prefixes it with its big-endian uint16 length,
+// per RFC 9250 §4.2.
+func frameDoQQuery(m *D.Msg) ([]byte, error) {
+	buf, err := m.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	framed := make([]byte, 2+len(buf))
+	binary.BigEndian.PutUint16(framed, uint16(len(buf)))
+	copy(framed[2:], buf)
+	return framed, nil
+}
+
+// unframeDoQResponse strips the RFC 9250 length prefix and unpacks the
+// remaining bytes as a DNS message.
+func unframeDoQResponse(raw []byte) (*D.Msg, error) {
+	if len(raw) < 2 {
+		return nil, errors.New("doq: response too short")
+	}
+
+	length := binary.BigEndian.Uint16(raw)
+	if int(length) != len(raw)-2 {
+		return nil, errors.New("doq: response length mismatch")
+	}
+
+	msg := &D.Msg{}
+	if err := msg.Unpack(raw[2:]); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func newDoQClient(addr string, r *Resolver, iface string, proxyAdapter string) *doqClient {
+	return &doqClient{
+		addr:         addr,
+		r:            r,
+		iface:        iface,
+		proxyAdapter: proxyAdapter,
+	}
+}
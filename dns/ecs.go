@@ -0,0 +1,105 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+
+	D "github.com/miekg/dns"
+)
+
+// defaultClientSubnetBits is the EDNS0 Client Subnet source prefix length
+// applied when ParseClientSubnet is given a bare address instead of a
+// prefix - /24 for IPv4 and /56 for IPv6, the same defaults Xray's
+// per-nameserver clientip option uses.
+func defaultClientSubnetBits(addr netip.Addr) int {
+	if addr.Is4() {
+		return 24
+	}
+	return 56
+}
+
+// ParseClientSubnet parses the `client-subnet` config value, either a bare
+// address ("1.2.3.4") or an explicit prefix ("1.2.3.0/24"). An empty string
+// returns the zero Prefix, which IsValid reports as false.
+func ParseClientSubnet(s string) (netip.Prefix, error) {
+	if s == "" {
+		return netip.Prefix{}, nil
+	}
+
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid client-subnet %q: %w", s, err)
+	}
+
+	return netip.PrefixFrom(addr, defaultClientSubnetBits(addr)).Masked(), nil
+}
+
+// setClientSubnet attaches prefix as m's EDNS0 Client Subnet option,
+// replacing any subnet option already present and adding an OPT record if
+// m doesn't carry one yet.
+func setClientSubnet(m *D.Msg, prefix netip.Prefix) {
+	opt := m.IsEdns0()
+	if opt == nil {
+		m.SetEdns0(4096, false)
+		opt = m.IsEdns0()
+	}
+
+	addr := prefix.Addr()
+	family := uint16(1)
+	if !addr.Is4() {
+		family = 2
+	}
+
+	options := stripSubnetOptions(opt.Option)
+	opt.Option = append(options, &D.EDNS0_SUBNET{
+		Code:          D.EDNS0SUBNET,
+		Family:        family,
+		SourceNetmask: uint8(prefix.Bits()),
+		Address:       net.IP(addr.AsSlice()),
+	})
+}
+
+// stripClientSubnet removes any EDNS0 Client Subnet option from m. Nothing
+// in this tree calls it; the inbound DNS listener has no no-ecs mode.
+func stripClientSubnet(m *D.Msg) {
+	opt := m.IsEdns0()
+	if opt == nil {
+		return
+	}
+	opt.Option = stripSubnetOptions(opt.Option)
+}
+
+func stripSubnetOptions(in []D.EDNS0) []D.EDNS0 {
+	out := in[:0]
+	for _, o := range in {
+		if o.Option() != D.EDNS0SUBNET {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// ecsClient wraps a dnsClient to attach a fixed Client Subnet override to
+// every query, for a NameServer's per-server `client-subnet` override -
+// analogous to Xray's per-nameserver clientip - taking precedence over the
+// resolver-wide ClientSubnet.
+type ecsClient struct {
+	dnsClient
+	prefix netip.Prefix
+}
+
+func (c *ecsClient) Exchange(m *D.Msg) (*D.Msg, error) {
+	return c.ExchangeContext(context.Background(), m)
+}
+
+func (c *ecsClient) ExchangeContext(ctx context.Context, m *D.Msg) (*D.Msg, error) {
+	mm := m.Copy()
+	setClientSubnet(mm, c.prefix)
+	return c.dnsClient.ExchangeContext(ctx, mm)
+}
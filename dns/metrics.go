@@ -0,0 +1,36 @@
+package dns
+
+import (
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+var (
+	cacheHitsTotal     atomic.Uint64
+	queryDurationSumNs atomic.Uint64
+	queryCount         atomic.Uint64
+)
+
+// CacheHits returns the number of DNS lookups served from the resolver's
+// LRU cache since start-up, for consumers such as the Prometheus exporter's
+// clash_dns_cache_hits_total.
+func CacheHits() uint64 {
+	return cacheHitsTotal.Load()
+}
+
+// QueryDurations returns the cumulative query latency and sample count
+// recorded since start-up, for consumers such as the Prometheus exporter's
+// clash_dns_query_duration_seconds histogram.
+func QueryDurations() (sum time.Duration, count uint64) {
+	return time.Duration(queryDurationSumNs.Load()), queryCount.Load()
+}
+
+func recordCacheHit() {
+	cacheHitsTotal.Inc()
+}
+
+func recordQueryDuration(d time.Duration) {
+	queryDurationSumNs.Add(uint64(d))
+	queryCount.Inc()
+}
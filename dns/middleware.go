@@ -0,0 +1,332 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	D "github.com/miekg/dns"
+
+	"github.com/Dreamacro/clash/component/trie"
+)
+
+// Handler answers a single DNS query, the same shape exchangeWithoutCache's
+// dispatch step already had before it became composable.
+type Handler func(ctx context.Context, m *D.Msg) (*D.Msg, error)
+
+// Middleware wraps a Handler with cross-cutting behavior - logging,
+// rewriting, rate-limiting - composing the same way net/http middleware
+// does. Cache lookup, hosts resolution, and policy/fallback selection stay
+// outside the chain: each already has its own short-circuit contract
+// (stale-cache refresh, CNAME recursion, singleflight dedup) that doesn't
+// factor into a generic next-calling Handler without duplicating it.
+type Middleware func(Handler) Handler
+
+// chainMiddlewares composes middlewares around base, the first entry
+// becoming the outermost wrapper.
+func chainMiddlewares(base Handler, middlewares []Middleware) Handler {
+	h := base
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// QueryLogEntry is one structured record of a completed DNS exchange.
+type QueryLogEntry struct {
+	Time     time.Time
+	Question string
+	Qtype    string
+	RTT      time.Duration
+	Rcode    int
+	Answers  []string
+}
+
+// queryLogRing is a fixed-capacity ring buffer of the most recent query log
+// entries, read through Snapshot and Subscribe.
+type queryLogRing struct {
+	mu      sync.Mutex
+	entries []QueryLogEntry
+	cap     int
+	next    int
+	subs    map[chan QueryLogEntry]struct{}
+}
+
+func newQueryLogRing(capacity int) *queryLogRing {
+	return &queryLogRing{cap: capacity, subs: map[chan QueryLogEntry]struct{}{}}
+}
+
+func (q *queryLogRing) push(e QueryLogEntry) {
+	q.mu.Lock()
+	if len(q.entries) < q.cap {
+		q.entries = append(q.entries, e)
+	} else {
+		q.entries[q.next] = e
+		q.next = (q.next + 1) % q.cap
+	}
+
+	subs := make([]chan QueryLogEntry, 0, len(q.subs))
+	for ch := range q.subs {
+		subs = append(subs, ch)
+	}
+	q.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Snapshot returns the ring's entries, oldest first.
+func (q *queryLogRing) Snapshot() []QueryLogEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	out := make([]QueryLogEntry, 0, len(q.entries))
+	if len(q.entries) < q.cap {
+		return append(out, q.entries...)
+	}
+	out = append(out, q.entries[q.next:]...)
+	out = append(out, q.entries[:q.next]...)
+	return out
+}
+
+// Subscribe registers ch to receive every future entry; an entry is
+// dropped for a subscriber whose channel is full. The caller must
+// Unsubscribe when done.
+func (q *queryLogRing) Subscribe(ch chan QueryLogEntry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.subs[ch] = struct{}{}
+}
+
+func (q *queryLogRing) Unsubscribe(ch chan QueryLogEntry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.subs, ch)
+}
+
+// DefaultQueryLog is the process-wide query log ring. Nothing outside this
+// package reads it; the hub API has no route for it.
+var DefaultQueryLog = newQueryLogRing(200)
+
+// LoggingMiddleware records every query/response pair to DefaultQueryLog.
+func LoggingMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, m *D.Msg) (*D.Msg, error) {
+			start := time.Now()
+			resp, err := next(ctx, m)
+
+			entry := QueryLogEntry{Time: start, RTT: time.Since(start)}
+			if len(m.Question) > 0 {
+				entry.Question = m.Question[0].Name
+				entry.Qtype = D.TypeToString[m.Question[0].Qtype]
+			}
+			switch {
+			case err != nil:
+				entry.Rcode = D.RcodeServerFailure
+			case resp != nil:
+				entry.Rcode = resp.Rcode
+				for _, rr := range resp.Answer {
+					entry.Answers = append(entry.Answers, rr.String())
+				}
+			}
+			DefaultQueryLog.push(entry)
+
+			return resp, err
+		}
+	}
+}
+
+// RewriteRule is one DNS rewrite-rule entry, matched against Domain using
+// the same suffix-wildcard ("+.example.com") syntax as an inline rule.
+type RewriteRule struct {
+	Domain string
+
+	// DropAAAA answers AAAA queries for Domain with an empty NOERROR
+	// instead of forwarding them upstream.
+	DropAAAA bool
+
+	// NXDOMAIN answers every query for Domain with NXDOMAIN.
+	NXDOMAIN bool
+
+	// Answer synthesizes a static A/AAAA response for Domain, for a
+	// self-hosted zone, from the addresses of the queried family; a query
+	// of another type, or one with no address of its family here, still
+	// goes upstream. TTL of 0 uses defaultHostsTTL.
+	Answer []netip.Addr
+	TTL    uint32
+}
+
+// RewriteRules is a compiled set of RewriteRule, looked up by domain.
+type RewriteRules struct {
+	trie *trie.DomainTrie[*RewriteRule]
+}
+
+// NewRewriteRules compiles rules into a RewriteRules ready for
+// RewriteMiddleware. A nil/empty rules matches nothing.
+func NewRewriteRules(rules []RewriteRule) (*RewriteRules, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	t := trie.New[*RewriteRule]()
+	for i := range rules {
+		rule := rules[i]
+		if err := t.Insert(rule.Domain, &rule); err != nil {
+			return nil, fmt.Errorf("dns rewrite rule %q: %w", rule.Domain, err)
+		}
+	}
+	return &RewriteRules{trie: t}, nil
+}
+
+// RewriteMiddleware applies rules before dispatch: NXDOMAIN rules answer
+// without ever calling next; DropAAAA only short-circuits AAAA questions;
+// static-zone rules answer A/AAAA questions that have an address of the
+// queried family and pass everything else to next.
+func RewriteMiddleware(rules *RewriteRules) Middleware {
+	return func(next Handler) Handler {
+		if rules == nil {
+			return next
+		}
+
+		return func(ctx context.Context, m *D.Msg) (*D.Msg, error) {
+			if len(m.Question) == 0 {
+				return next(ctx, m)
+			}
+
+			q := m.Question[0]
+			node := rules.trie.Search(strings.TrimRight(q.Name, "."))
+			if node == nil {
+				return next(ctx, m)
+			}
+			rule := node.Data
+
+			switch {
+			case rule.NXDOMAIN:
+				resp := &D.Msg{}
+				resp.SetRcode(m, D.RcodeNameError)
+				return resp, nil
+			case rule.DropAAAA && q.Qtype == D.TypeAAAA:
+				return handleMsgWithEmptyAnswer(m), nil
+			case len(rule.Answer) > 0 && isIPRequest(q):
+				if resp := rewriteStaticAnswer(m, q, rule); resp != nil {
+					return resp, nil
+				}
+			}
+
+			return next(ctx, m)
+		}
+	}
+}
+
+func rewriteStaticAnswer(m *D.Msg, q D.Question, rule *RewriteRule) *D.Msg {
+	ttl := rule.TTL
+	if ttl == 0 {
+		ttl = defaultHostsTTL
+	}
+
+	resp := &D.Msg{}
+	resp.SetReply(m)
+	resp.Authoritative = true
+
+	for _, ip := range rule.Answer {
+		switch {
+		case q.Qtype == D.TypeA && ip.Is4():
+			resp.Answer = append(resp.Answer, &D.A{
+				Hdr: D.RR_Header{Name: q.Name, Rrtype: D.TypeA, Class: D.ClassINET, Ttl: ttl},
+				A:   net.IP(ip.AsSlice()),
+			})
+		case q.Qtype == D.TypeAAAA && !ip.Is4():
+			resp.Answer = append(resp.Answer, &D.AAAA{
+				Hdr:  D.RR_Header{Name: q.Name, Rrtype: D.TypeAAAA, Class: D.ClassINET, Ttl: ttl},
+				AAAA: net.IP(ip.AsSlice()),
+			})
+		}
+	}
+
+	if len(resp.Answer) == 0 {
+		return nil
+	}
+	return resp
+}
+
+// clientIPContextKey is the context key WithClientIP stores the querying
+// client's address under, for RateLimitMiddleware to key on.
+type clientIPContextKey struct{}
+
+// WithClientIP attaches the querying client's address to ctx.
+func WithClientIP(ctx context.Context, ip netip.Addr) context.Context {
+	return context.WithValue(ctx, clientIPContextKey{}, ip)
+}
+
+func clientIPFromContext(ctx context.Context) (netip.Addr, bool) {
+	ip, ok := ctx.Value(clientIPContextKey{}).(netip.Addr)
+	return ip, ok
+}
+
+// rateLimitBucket is a per-client token bucket.
+type rateLimitBucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	lastFill time.Time
+}
+
+func (b *rateLimitBucket) allow(rps float64, burst int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.lastFill).Seconds() * rps
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.lastFill = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RateLimitMiddleware caps each client IP to rps queries per second (with
+// bursting up to burst), refusing anything over the limit instead of
+// forwarding it. Queries with no client IP attached to ctx (WithClientIP
+// wasn't called) are never limited, and no listener in this tree calls
+// WithClientIP.
+func RateLimitMiddleware(rps float64, burst int) Middleware {
+	var mu sync.Mutex
+	buckets := map[netip.Addr]*rateLimitBucket{}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, m *D.Msg) (*D.Msg, error) {
+			ip, ok := clientIPFromContext(ctx)
+			if !ok {
+				return next(ctx, m)
+			}
+
+			mu.Lock()
+			b, ok := buckets[ip]
+			if !ok {
+				b = &rateLimitBucket{tokens: float64(burst), lastFill: time.Now()}
+				buckets[ip] = b
+			}
+			mu.Unlock()
+
+			if !b.allow(rps, burst) {
+				resp := &D.Msg{}
+				resp.SetRcode(m, D.RcodeRefused)
+				return resp, nil
+			}
+
+			return next(ctx, m)
+		}
+	}
+}
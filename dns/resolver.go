@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
 	"net/netip"
 	"strings"
 	"time"
@@ -33,9 +34,13 @@ type result struct {
 	Error error
 }
 
+// defaultHostsTTL is the answer TTL a synthesized hosts response carries
+// when its HostValue didn't set its own.
+const defaultHostsTTL = 60
+
 type Resolver struct {
 	ipv6                  bool
-	hosts                 *trie.DomainTrie[netip.Addr]
+	hosts                 *trie.DomainTrie[*resolver.HostValue]
 	main                  []dnsClient
 	fallback              []dnsClient
 	fallbackDomainFilters []fallbackDomainFilter
@@ -44,10 +49,58 @@ type Resolver struct {
 	lruCache              *cache.LruCache[string, *D.Msg]
 	policy                *trie.DomainTrie[*Policy]
 	proxyServer           []dnsClient
+	queryStrategy         QueryStrategy
+	disableCache          bool
+	disableFallback       bool
+	clientSubnet          netip.Prefix
+	middlewares           []Middleware
+}
+
+// QueryStrategy controls which record types ResolveIP queries for.
+type QueryStrategy int
+
+const (
+	QueryStrategyUseIPv4v6 QueryStrategy = iota
+	QueryStrategyUseIPv4
+	QueryStrategyUseIPv6
+)
+
+// NewQueryStrategy parses the `query-strategy` config value. An empty
+// string is equivalent to "UseIPv4v6", today's always-both behavior.
+func NewQueryStrategy(s string) (QueryStrategy, error) {
+	switch s {
+	case "", "UseIPv4v6":
+		return QueryStrategyUseIPv4v6, nil
+	case "UseIPv4":
+		return QueryStrategyUseIPv4, nil
+	case "UseIPv6":
+		return QueryStrategyUseIPv6, nil
+	default:
+		return 0, fmt.Errorf("unsupported query-strategy %q", s)
+	}
 }
 
-// ResolveIP request with TypeA and TypeAAAA, priority return TypeA
+func (qs QueryStrategy) String() string {
+	switch qs {
+	case QueryStrategyUseIPv4:
+		return "UseIPv4"
+	case QueryStrategyUseIPv6:
+		return "UseIPv6"
+	default:
+		return "UseIPv4v6"
+	}
+}
+
+// ResolveIP request with TypeA and/or TypeAAAA according to queryStrategy,
+// priority return TypeA when both are queried
 func (r *Resolver) ResolveIP(ctx context.Context, host string) (ip netip.Addr, err error) {
+	switch r.queryStrategy {
+	case QueryStrategyUseIPv4:
+		return r.resolveIP(ctx, host, D.TypeA)
+	case QueryStrategyUseIPv6:
+		return r.resolveIP(ctx, host, D.TypeAAAA)
+	}
+
 	ch := make(chan netip.Addr, 1)
 	go func() {
 		defer close(ch)
@@ -81,6 +134,16 @@ func (r *Resolver) ResolveIPv6(ctx context.Context, host string) (ip netip.Addr,
 	return r.resolveIP(ctx, host, D.TypeAAAA)
 }
 
+// cacheKey builds the lruCache key for q, folding in the resolver-wide
+// ClientSubnet (when set) so distinct ECS values don't share a cache entry
+// for answers that differ by requester location.
+func (r *Resolver) cacheKey(q D.Question) string {
+	if !r.clientSubnet.IsValid() {
+		return q.String()
+	}
+	return q.String() + " " + r.clientSubnet.String()
+}
+
 func (r *Resolver) shouldIPFallback(ip netip.Addr) bool {
 	for _, filter := range r.fallbackIPFilters {
 		if filter.Match(ip) {
@@ -101,9 +164,19 @@ func (r *Resolver) ExchangeContext(ctx context.Context, m *D.Msg) (msg *D.Msg, e
 		return nil, errors.New("should have one question at least")
 	}
 
+	start := time.Now()
+	defer func() { recordQueryDuration(time.Since(start)) }()
+
 	q := m.Question[0]
-	cacheM, expireTime, hit := r.lruCache.GetWithExpire(q.String())
+
+	if r.disableCache {
+		return r.exchangeWithoutCache(ctx, m)
+	}
+
+	key := r.cacheKey(q)
+	cacheM, expireTime, hit := r.lruCache.GetWithExpire(key)
 	if hit {
+		recordCacheHit()
 		now := time.Now()
 		msg = cacheM.Copy()
 		if expireTime.Before(now) {
@@ -122,27 +195,41 @@ func (r *Resolver) ExchangeContext(ctx context.Context, m *D.Msg) (msg *D.Msg, e
 // ExchangeWithoutCache a batch of dns request, and it does NOT GET from cache
 func (r *Resolver) exchangeWithoutCache(ctx context.Context, m *D.Msg) (msg *D.Msg, err error) {
 	q := m.Question[0]
+	key := r.cacheKey(q)
+
+	if r.clientSubnet.IsValid() {
+		mm := m.Copy()
+		setClientSubnet(mm, r.clientSubnet)
+		m = mm
+	}
 
-	ret, err, shared := r.group.Do(q.String(), func() (result any, err error) {
+	ret, err, shared := r.group.Do(key, func() (result any, err error) {
 		defer func() {
-			if err != nil {
+			if err != nil || r.disableCache {
 				return
 			}
 
 			msg := result.(*D.Msg)
 
-			putMsgToCache(r.lruCache, q.String(), msg)
+			putMsgToCache(r.lruCache, key, msg)
 		}()
 
-		isIPReq := isIPRequest(q)
-		if isIPReq {
-			return r.ipExchange(ctx, m)
-		}
+		dispatch := func(ctx context.Context, m *D.Msg) (*D.Msg, error) {
+			q := m.Question[0]
+			if isIPRequest(q) {
+				if hostsMsg, ok := r.hostsAnswer(ctx, m, q); ok {
+					return hostsMsg, nil
+				}
+				return r.ipExchange(ctx, m)
+			}
 
-		if matched := r.matchPolicy(m); len(matched) != 0 {
-			return r.batchExchange(ctx, matched, m)
+			if matched := r.matchPolicy(m); len(matched) != 0 {
+				return r.batchExchange(ctx, matched, m)
+			}
+			return r.batchExchange(ctx, r.main, m)
 		}
-		return r.batchExchange(ctx, r.main, m)
+
+		return chainMiddlewares(dispatch, r.middlewares)(ctx, m)
 	})
 
 	if err == nil {
@@ -183,6 +270,10 @@ func (r *Resolver) batchExchange(ctx context.Context, clients []dnsClient, m *D.
 	return
 }
 
+// matchPolicy returns the nameservers a matched domain policy should use.
+// A matched policy's servers are queried exclusively - the caller never
+// falls back to r.fallback - so per-policy DisableFallback is implicit in
+// this contract rather than a separate toggle to check.
 func (r *Resolver) matchPolicy(m *D.Msg) []dnsClient {
 	if r.policy == nil {
 		return nil
@@ -222,6 +313,93 @@ func (r *Resolver) shouldOnlyQueryFallback(m *D.Msg) bool {
 	return false
 }
 
+// hostsAnswer synthesizes a DNS response for a hosts-matched A/AAAA query:
+// a multi-answer response from a multi-IP entry, or - for a CNAME entry -
+// a CNAME record followed by whatever the chain's final domain resolves to
+// (upstream, if the chain didn't reach a literal IP within the hop limit).
+// ok is false when there's no hosts entry for q, or the matched entry has
+// no address of the requested family, so the caller falls through to the
+// normal upstream path.
+func (r *Resolver) hostsAnswer(ctx context.Context, m *D.Msg, q D.Question) (msg *D.Msg, ok bool) {
+	if r.hosts == nil {
+		return nil, false
+	}
+
+	host := strings.TrimRight(q.Name, ".")
+	value, domain, found := resolver.LookupHostsIn(r.hosts, host)
+	if !found {
+		return nil, false
+	}
+
+	msg = &D.Msg{}
+	msg.SetReply(m)
+	msg.Authoritative = true
+	msg.RecursionAvailable = true
+
+	if domain != host {
+		msg.Answer = append(msg.Answer, &D.CNAME{
+			Hdr:    D.RR_Header{Name: q.Name, Rrtype: D.TypeCNAME, Class: D.ClassINET, Ttl: defaultHostsTTL},
+			Target: D.Fqdn(domain),
+		})
+	}
+
+	if value == nil {
+		// the CNAME chain didn't terminate in a literal IP within the hop
+		// limit - resolve its final target upstream and graft the result
+		// onto the CNAME record already emitted.
+		upstream := &D.Msg{}
+		upstream.SetQuestion(D.Fqdn(domain), q.Qtype)
+
+		resolved, err := r.exchangeWithoutCache(ctx, upstream)
+		if err != nil {
+			return nil, false
+		}
+
+		msg.Answer = append(msg.Answer, resolved.Answer...)
+		msg.Rcode = resolved.Rcode
+		return msg, true
+	}
+
+	ttl := uint32(defaultHostsTTL)
+	if value.TTL != 0 {
+		ttl = value.TTL
+	}
+
+	switch q.Qtype {
+	case D.TypeA:
+		for _, ip := range value.IPs {
+			if !ip.Is4() {
+				continue
+			}
+			msg.Answer = append(msg.Answer, &D.A{
+				Hdr: D.RR_Header{Name: D.Fqdn(domain), Rrtype: D.TypeA, Class: D.ClassINET, Ttl: ttl},
+				A:   net.IP(ip.AsSlice()),
+			})
+		}
+	case D.TypeAAAA:
+		for _, ip := range value.IPs {
+			if ip.Is4() {
+				continue
+			}
+			msg.Answer = append(msg.Answer, &D.AAAA{
+				Hdr:  D.RR_Header{Name: D.Fqdn(domain), Rrtype: D.TypeAAAA, Class: D.ClassINET, Ttl: ttl},
+				AAAA: net.IP(ip.AsSlice()),
+			})
+		}
+	default:
+		return nil, false
+	}
+
+	if len(msg.Answer) == 0 {
+		// matched but nothing of the requested family (e.g. an IPv4-only
+		// entry queried for AAAA) - let the caller fall through upstream
+		// rather than answer with an empty NOERROR.
+		return nil, false
+	}
+
+	return msg, true
+}
+
 func (r *Resolver) ipExchange(ctx context.Context, m *D.Msg) (msg *D.Msg, err error) {
 	if matched := r.matchPolicy(m); len(matched) != 0 {
 		res := <-r.asyncExchange(ctx, matched, m)
@@ -237,7 +415,7 @@ func (r *Resolver) ipExchange(ctx context.Context, m *D.Msg) (msg *D.Msg, err er
 
 	msgCh := r.asyncExchange(ctx, r.main, m)
 
-	if r.fallback == nil || len(r.fallback) == 0 { // directly return if no fallback servers are available
+	if r.disableFallback || r.fallback == nil || len(r.fallback) == 0 { // directly return if no fallback servers are available
 		res := <-msgCh
 		msg, err = res.Msg, res.Error
 		return
@@ -323,6 +501,29 @@ type NameServer struct {
 	Addr         string
 	Interface    string
 	ProxyAdapter string
+
+	// DoHMethod selects the HTTP method used by "https" name servers.
+	// Empty defaults to POST; "GET" uses the cache-friendly encoding from
+	// RFC 8484 §4.1.
+	DoHMethod string
+
+	// DoH3 requests an HTTP/3 transport for "https" name servers. This build
+	// has no HTTP/3 transport, so the client always uses HTTP/2; DoH3Fallback
+	// only decides whether that is logged as a warning (unset) or at debug
+	// level (set).
+	DoH3         bool
+	DoH3Fallback bool
+
+	// ClientFingerprint selects a uTLS ClientHelloID ("chrome", "firefox",
+	// ...) for "https" name servers. This build has no uTLS client, so a
+	// name server with a fingerprint set fails every query.
+	ClientFingerprint string
+
+	// ClientSubnet overrides the resolver-wide ClientSubnet for this
+	// nameserver only, as a bare address or an explicit prefix - e.g. a
+	// nameserver known to be geolocated for one region can be queried
+	// with that region's subnet regardless of the client's own.
+	ClientSubnet string
 }
 
 type FallbackFilter struct {
@@ -341,8 +542,43 @@ type Config struct {
 	EnhancedMode   C.DNSMode
 	FallbackFilter FallbackFilter
 	Pool           *fakeip.Pool
-	Hosts          *trie.DomainTrie[netip.Addr]
+	Hosts          *trie.DomainTrie[*resolver.HostValue]
 	Policy         map[string]NameServer
+
+	// QueryStrategy controls whether ResolveIP queries A, AAAA, or both in
+	// parallel. Defaults to QueryStrategyUseIPv4v6, today's always-both
+	// behavior.
+	QueryStrategy QueryStrategy
+
+	// DisableCache bypasses lruCache reads/writes entirely, for callers
+	// that want every query hitting upstream (e.g. testing, or a resolver
+	// whose answers must never be stale).
+	DisableCache bool
+
+	// DisableFallback makes ipExchange never consult Fallback, even when
+	// fallback servers are configured - useful on an IPv4-only network
+	// where a configured fallback set would otherwise add latency to
+	// every query without ever winning.
+	DisableFallback bool
+
+	// ClientSubnet, if valid, is attached to every outgoing query as an
+	// EDNS0 Client Subnet option, unless a NameServer sets its own
+	// ClientSubnet override.
+	ClientSubnet netip.Prefix
+
+	// EnableQueryLog records every query/response pair to DefaultQueryLog.
+	// No hub API route reads that log.
+	EnableQueryLog bool
+
+	// Rewrite rules are checked before every query is dispatched upstream,
+	// to drop AAAA, force NXDOMAIN, or answer from a static zone.
+	Rewrite []RewriteRule
+
+	// RateLimit, when non-zero, caps each client IP (as attached to the
+	// query context by WithClientIP) to RateLimit queries per second, with
+	// bursting up to RateLimitBurst.
+	RateLimit      float64
+	RateLimitBurst int
 }
 
 func NewResolver(config Config) *Resolver {
@@ -352,10 +588,24 @@ func NewResolver(config Config) *Resolver {
 	}
 
 	r := &Resolver{
-		ipv6:     config.IPv6,
-		main:     transform(config.Main, defaultResolver),
-		lruCache: cache.New[string, *D.Msg](cache.WithSize[string, *D.Msg](4096), cache.WithStale[string, *D.Msg](true)),
-		hosts:    config.Hosts,
+		ipv6:            config.IPv6,
+		main:            transform(config.Main, defaultResolver),
+		lruCache:        cache.New[string, *D.Msg](cache.WithSize[string, *D.Msg](4096), cache.WithStale[string, *D.Msg](true)),
+		hosts:           config.Hosts,
+		queryStrategy:   config.QueryStrategy,
+		disableCache:    config.DisableCache,
+		disableFallback: config.DisableFallback,
+		clientSubnet:    config.ClientSubnet,
+	}
+
+	if config.EnableQueryLog {
+		r.middlewares = append(r.middlewares, LoggingMiddleware())
+	}
+	if rewrite, err := NewRewriteRules(config.Rewrite); err == nil && rewrite != nil {
+		r.middlewares = append(r.middlewares, RewriteMiddleware(rewrite))
+	}
+	if config.RateLimit > 0 {
+		r.middlewares = append(r.middlewares, RateLimitMiddleware(config.RateLimit, config.RateLimitBurst))
 	}
 
 	if len(config.Fallback) != 0 {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"strings"
 	"time"
 
 	D "github.com/miekg/dns"
@@ -60,31 +61,45 @@ func isIPRequest(q D.Question) bool {
 func transform(servers []NameServer, resolver *Resolver) []dnsClient {
 	ret := []dnsClient{}
 	for _, s := range servers {
+		var cli dnsClient
+
 		switch s.Net {
 		case "https":
-			ret = append(ret, newDoHClient(s.Addr, resolver, s.ProxyAdapter))
-			continue
+			useGET := strings.EqualFold(s.DoHMethod, "GET")
+			cli = newDoHClient(s.Addr, resolver, s.ProxyAdapter, useGET, s.DoH3, s.DoH3Fallback, s.ClientFingerprint)
 		case "dhcp":
-			ret = append(ret, newDHCPClient(s.Addr))
-			continue
+			cli = newDHCPClient(s.Addr)
+		case "quic":
+			log.Warnln("[DNS] nameserver %s: quic transport not built into this binary, every query to this nameserver will fail", s.Addr)
+			cli = newDoQClient(s.Addr, resolver, s.Interface, s.ProxyAdapter)
+		default:
+			host, port, _ := net.SplitHostPort(s.Addr)
+			cli = &client{
+				Client: &D.Client{
+					Net: s.Net,
+					TLSConfig: &tls.Config{
+						ServerName: host,
+					},
+					UDPSize: 4096,
+					Timeout: 5 * time.Second,
+				},
+				port:         port,
+				host:         host,
+				iface:        s.Interface,
+				r:            resolver,
+				proxyAdapter: s.ProxyAdapter,
+			}
 		}
 
-		host, port, _ := net.SplitHostPort(s.Addr)
-		ret = append(ret, &client{
-			Client: &D.Client{
-				Net: s.Net,
-				TLSConfig: &tls.Config{
-					ServerName: host,
-				},
-				UDPSize: 4096,
-				Timeout: 5 * time.Second,
-			},
-			port:         port,
-			host:         host,
-			iface:        s.Interface,
-			r:            resolver,
-			proxyAdapter: s.ProxyAdapter,
-		})
+		if s.ClientSubnet != "" {
+			if prefix, err := ParseClientSubnet(s.ClientSubnet); err == nil && prefix.IsValid() {
+				cli = &ecsClient{dnsClient: cli, prefix: prefix}
+			} else if err != nil {
+				log.Warnln("[DNS] nameserver %s: %s", s.Addr, err)
+			}
+		}
+
+		ret = append(ret, cli)
 	}
 	return ret
 }
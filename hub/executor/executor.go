@@ -2,7 +2,6 @@ package executor
 
 import (
 	"fmt"
-	"net/netip"
 	"os"
 	"sync"
 
@@ -14,6 +13,7 @@ import (
 	"github.com/Dreamacro/clash/component/profile"
 	"github.com/Dreamacro/clash/component/profile/cachefile"
 	"github.com/Dreamacro/clash/component/resolver"
+	"github.com/Dreamacro/clash/component/sniffer"
 	"github.com/Dreamacro/clash/component/trie"
 	"github.com/Dreamacro/clash/config"
 	C "github.com/Dreamacro/clash/constant"
@@ -63,8 +63,10 @@ func ParseWithBytes(buf []byte) (*config.Config, error) {
 	return config.Parse(buf)
 }
 
-// ApplyConfig dispatch configure to all parts
-func ApplyConfig(cfg *config.Config, force bool) {
+// ApplyConfig dispatch configure to all parts. source records where cfg
+// came from ("path", "payload" or "rollback") so GET /configs/history can
+// show it back to the operator.
+func ApplyConfig(cfg *config.Config, force bool, source string) {
 	mux.Lock()
 	defer mux.Unlock()
 
@@ -86,6 +88,8 @@ func ApplyConfig(cfg *config.Config, force bool) {
 	updateExperimental(cfg)
 
 	log.SetLevel(cfg.General.LogLevel)
+
+	recordHistory(cfg, source)
 }
 
 func GetGeneral() *config.General {
@@ -181,7 +185,7 @@ func updateDNS(c *config.DNS, t *config.Tun) {
 	}
 }
 
-func updateHosts(tree *trie.DomainTrie[netip.Addr]) {
+func updateHosts(tree *trie.DomainTrie[*resolver.HostValue]) {
 	resolver.DefaultHosts = tree
 }
 
@@ -225,10 +229,13 @@ func updateGeneral(general *config.General, force bool) {
 	bindAddress := general.BindAddress
 	P.SetBindAddress(bindAddress)
 
-	sniffing := general.Sniffing
-	tunnel.SetSniffing(sniffing)
-
-	log.Infoln("Use TLS SNI sniffer: %v", sniffing)
+	if general.Sniffer.Enable {
+		tunnel.SetSniffer(newSnifferSet(general.Sniffer))
+		log.Infoln("Sniffer is enabled, TLS/HTTP/QUIC sniffing on ports: %v", general.Sniffer.Ports)
+	} else {
+		tunnel.SetSniffer(nil)
+		log.Infoln("Sniffer is disabled")
+	}
 
 	tcpIn := tunnel.TCPIn()
 	udpIn := tunnel.UDPIn()
@@ -244,6 +251,13 @@ func updateGeneral(general *config.General, force bool) {
 	P.ReCreateRedirToTun(general.EBpf.RedirectToTun)
 }
 
+// newSnifferSet builds the pluggable sniffer set for cfg, replacing the
+// hard-coded TLS-port whitelist with cfg.Ports/ForceDomain/SkipDomain.
+func newSnifferSet(cfg config.Sniffer) *sniffer.Set {
+	sniffers := []sniffer.Sniffer{sniffer.TLSSniffer{}, sniffer.HTTPSniffer{}, sniffer.QUICSniffer{}}
+	return sniffer.NewSet(sniffers, cfg.Ports, cfg.ForceDomain, cfg.SkipDomain, cfg.OverrideDestination)
+}
+
 func updateUsers(users []auth.AuthUser) {
 	authenticator := auth.NewAuthenticator(users)
 	authStore.SetAuthenticator(authenticator)
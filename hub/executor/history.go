@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Dreamacro/clash/config"
+)
+
+// maxHistorySize bounds the in-memory ring of previously applied configs -
+// large enough to undo a string of bad PATCH/PUT calls in a row, small
+// enough that a long-running process doesn't accumulate unbounded *config.Config
+// snapshots.
+const maxHistorySize = 10
+
+// HistoryEntry describes one previously applied config snapshot, without
+// the config body itself - returned by GET /configs/history so operators can
+// pick an id to pass to POST /configs/rollback/{id}.
+type HistoryEntry struct {
+	ID        string    `json:"id"`
+	AppliedAt time.Time `json:"appliedAt"`
+	Source    string    `json:"source"`
+}
+
+type historySnapshot struct {
+	HistoryEntry
+	cfg *config.Config
+}
+
+var (
+	historyMux sync.Mutex
+	history    []*historySnapshot
+	historySeq uint64
+)
+
+// recordHistory appends cfg to the history ring under source ("path",
+// "payload" or "rollback"), evicting the oldest entry once maxHistorySize is
+// exceeded.
+func recordHistory(cfg *config.Config, source string) {
+	historyMux.Lock()
+	defer historyMux.Unlock()
+
+	historySeq++
+	history = append(history, &historySnapshot{
+		HistoryEntry: HistoryEntry{
+			ID:        fmt.Sprintf("%d", historySeq),
+			AppliedAt: time.Now(),
+			Source:    source,
+		},
+		cfg: cfg,
+	})
+
+	if len(history) > maxHistorySize {
+		history = history[len(history)-maxHistorySize:]
+	}
+}
+
+// History returns the retained applied-config snapshots, oldest first.
+func History() []HistoryEntry {
+	historyMux.Lock()
+	defer historyMux.Unlock()
+
+	entries := make([]HistoryEntry, 0, len(history))
+	for _, s := range history {
+		entries = append(entries, s.HistoryEntry)
+	}
+	return entries
+}
+
+// HistoryConfig returns the snapshot recorded under id, if it's still
+// retained in the ring.
+func HistoryConfig(id string) (*config.Config, bool) {
+	historyMux.Lock()
+	defer historyMux.Unlock()
+
+	for _, s := range history {
+		if s.ID == id {
+			return s.cfg, true
+		}
+	}
+	return nil, false
+}
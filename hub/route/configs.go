@@ -24,6 +24,8 @@ func configRouter() http.Handler {
 	r.Get("/", getConfigs)
 	r.Put("/", updateConfigs)
 	r.Patch("/", patchConfigs)
+	r.Get("/history", getConfigHistory)
+	r.Post("/rollback/{id}", rollbackConfig)
 	return r
 }
 
@@ -163,8 +165,10 @@ func updateConfigs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	force := r.URL.Query().Get("force") == "true"
+	dryRun := r.URL.Query().Get("dryRun") == "true"
 	var cfg *config.Config
 	var err error
+	source := "payload"
 
 	if req.Payload != "" {
 		log.Warnln("[RESTful API] update config by payload")
@@ -175,6 +179,7 @@ func updateConfigs(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	} else {
+		source = "path"
 		if req.Path == "" {
 			req.Path = constant.Path.Config()
 		}
@@ -193,6 +198,31 @@ func updateConfigs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	executor.ApplyConfig(cfg, force)
+	if dryRun {
+		log.Warnln("[RESTful API] dry run config from %s, nothing applied", source)
+		render.JSON(w, r, executor.Validate(cfg))
+		return
+	}
+
+	executor.ApplyConfig(cfg, force, source)
+	render.NoContent(w, r)
+}
+
+func getConfigHistory(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, render.M{"history": executor.History()})
+}
+
+func rollbackConfig(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	cfg, ok := executor.HistoryConfig(id)
+	if !ok {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, newError("no history entry with that id"))
+		return
+	}
+
+	log.Warnln("[RESTful API] rolling back config to history entry %s", id)
+	executor.ApplyConfig(cfg, true, "rollback")
 	render.NoContent(w, r)
 }
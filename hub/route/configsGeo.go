@@ -57,7 +57,7 @@ func updateGeoDatabases(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		executor.ApplyConfig(cfg, false)
+		executor.ApplyConfig(cfg, false, "path")
 	}()
 
 	render.NoContent(w, r)
@@ -0,0 +1,59 @@
+package filter
+
+import (
+	"regexp"
+	"time"
+)
+
+// literal is a parsed constant on the right-hand side of a comparison.
+type literal struct {
+	kind tokenKind // tokString, tokNumber or tokDuration
+	str  string
+	num  float64
+	dur  time.Duration
+}
+
+// node is a boolean expression that can be evaluated against one list item.
+type node interface {
+	eval(item any) (bool, error)
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) eval(item any) (bool, error) {
+	l, err := n.left.eval(item)
+	if err != nil || !l {
+		return false, err
+	}
+	return n.right.eval(item)
+}
+
+type orNode struct{ left, right node }
+
+func (n *orNode) eval(item any) (bool, error) {
+	l, err := n.left.eval(item)
+	if err != nil {
+		return false, err
+	}
+	if l {
+		return true, nil
+	}
+	return n.right.eval(item)
+}
+
+type notNode struct{ inner node }
+
+func (n *notNode) eval(item any) (bool, error) {
+	v, err := n.inner.eval(item)
+	return !v, err
+}
+
+// cmpNode compares the field named by path against a literal (==, !=, <,
+// <=, >, >=, contains, matches) or a set of literals (in).
+type cmpNode struct {
+	path string
+	op   tokenKind
+	lit  literal
+	list []literal
+	re   *regexp.Regexp
+}
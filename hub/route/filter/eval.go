@@ -0,0 +1,190 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// lookupField walks path (dot-separated) against item, matching struct
+// fields case-insensitively against their JSON tag (falling back to the Go
+// field name) so users can filter on exactly the names they see in the API
+// response, and matching map keys case-insensitively too.
+func lookupField(item any, path string) (reflect.Value, bool) {
+	v := reflect.ValueOf(item)
+	for _, part := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByJSONName(v, part)
+			if !ok {
+				return reflect.Value{}, false
+			}
+			v = field
+		case reflect.Map:
+			var found reflect.Value
+			ok := false
+			for _, key := range v.MapKeys() {
+				if strings.EqualFold(fmt.Sprint(key.Interface()), part) {
+					found = v.MapIndex(key)
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				return reflect.Value{}, false
+			}
+			v = found
+		default:
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		tagName := strings.Split(tag, ",")[0]
+		if tagName == "-" {
+			continue
+		}
+		if tagName == "" {
+			tagName = f.Name
+		}
+		if strings.EqualFold(tagName, name) || strings.EqualFold(f.Name, name) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func (n *cmpNode) eval(item any) (bool, error) {
+	field, ok := lookupField(item, n.path)
+	if !ok {
+		return false, nil
+	}
+	for field.Kind() == reflect.Pointer || field.Kind() == reflect.Interface {
+		if field.IsNil() {
+			return false, nil
+		}
+		field = field.Elem()
+	}
+
+	switch n.op {
+	case tokEq, tokNeq:
+		eq := compareEqual(field, n.lit)
+		if n.op == tokNeq {
+			return !eq, nil
+		}
+		return eq, nil
+	case tokLt, tokLte, tokGt, tokGte:
+		return compareOrdered(field, n.op, n.lit)
+	case tokIn:
+		for _, lit := range n.list {
+			if compareEqual(field, lit) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case tokContains:
+		return compareContains(field, n.lit)
+	case tokMatches:
+		return n.re.MatchString(toDisplayString(field)), nil
+	default:
+		return false, fmt.Errorf("filter: unsupported operator on %q", n.path)
+	}
+}
+
+func toDisplayString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func toFloat(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func compareEqual(field reflect.Value, lit literal) bool {
+	switch lit.kind {
+	case tokString:
+		return strings.EqualFold(toDisplayString(field), lit.str)
+	case tokNumber, tokDuration:
+		f, ok := toFloat(field)
+		return ok && f == litNumber(lit)
+	default:
+		return false
+	}
+}
+
+func compareOrdered(field reflect.Value, op tokenKind, lit literal) (bool, error) {
+	lf, ok := toFloat(field)
+	if !ok {
+		return false, nil
+	}
+	rf := litNumber(lit)
+
+	switch op {
+	case tokLt:
+		return lf < rf, nil
+	case tokLte:
+		return lf <= rf, nil
+	case tokGt:
+		return lf > rf, nil
+	case tokGte:
+		return lf >= rf, nil
+	default:
+		return false, fmt.Errorf("filter: unsupported ordering operator")
+	}
+}
+
+func compareContains(field reflect.Value, lit literal) (bool, error) {
+	switch field.Kind() {
+	case reflect.String:
+		return strings.Contains(strings.ToLower(field.String()), strings.ToLower(lit.str)), nil
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < field.Len(); i++ {
+			if compareEqual(field.Index(i), lit) {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, nil
+	}
+}
+
+// litNumber returns a duration literal in milliseconds and a plain number
+// literal as-is, so e.g. `Delay < 300` and `Delay < 300ms` both compare
+// against a millisecond-valued Delay field the same way.
+func litNumber(lit literal) float64 {
+	if lit.kind == tokDuration {
+		return float64(lit.dur.Milliseconds())
+	}
+	return lit.num
+}
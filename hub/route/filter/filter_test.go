@@ -0,0 +1,122 @@
+package filter
+
+import "testing"
+
+type filterTestItem struct {
+	Type  string   `json:"type"`
+	Name  string   `json:"name"`
+	Delay int      `json:"delay"`
+	Tags  []string `json:"tags"`
+}
+
+func TestCompileAndMatches(t *testing.T) {
+	item := filterTestItem{Type: "Vmess", Name: "JP-Tokyo-01", Delay: 120, Tags: []string{"jp", "low-latency"}}
+
+	cases := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{"eq case-insensitive", `Type == "vmess"`, true},
+		{"eq mismatch", `Type == "Trojan"`, false},
+		{"neq", `Type != "Trojan"`, true},
+		{"lt", `Delay < 300`, true},
+		{"lte boundary", `Delay <= 120`, true},
+		{"gt false", `Delay > 120`, false},
+		{"gte boundary", `Delay >= 120`, true},
+		{"duration literal", `Delay < 300ms`, true},
+		{"matches", `Name matches "^JP-"`, true},
+		{"matches no match", `Name matches "^US-"`, false},
+		{"contains on string field", `Name contains "tokyo"`, true},
+		{"contains on slice field", `Tags contains "jp"`, true},
+		{"contains on slice field miss", `Tags contains "kr"`, false},
+		{"in", `Type in ("Trojan", "Vmess")`, true},
+		{"in miss", `Type in ("Trojan", "Shadowsocks")`, false},
+		{"not", `not Type == "Trojan"`, true},
+		{"and", `Type == "Vmess" and Delay < 300`, true},
+		{"and short-circuit false", `Type == "Trojan" and Delay < 300`, false},
+		{"or", `Type == "Trojan" or Delay < 300`, true},
+		{"precedence: and binds tighter than or", `Type == "Vmess" or Type == "Trojan" and Delay > 300`, true},
+		{"parens override precedence", `(Type == "Vmess" or Type == "Trojan") and Delay > 300`, false},
+		{"unknown field misses rather than erroring", `Nonexistent == "x"`, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			expr, err := Compile(c.expr)
+			if err != nil {
+				t.Fatalf("Compile(%q) returned error: %v", c.expr, err)
+			}
+			got, err := expr.Matches(item)
+			if err != nil {
+				t.Fatalf("Matches(%q) returned error: %v", c.expr, err)
+			}
+			if got != c.want {
+				t.Errorf("Matches(%q) = %v, want %v", c.expr, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := []string{
+		``,
+		`Type ==`,
+		`Type == "unterminated`,
+		`Type == "Vmess" and`,
+		`(Type == "Vmess"`,
+		`Type === "Vmess"`,
+		`Name matches "["`,
+		`Type == "Vmess" extra`,
+	}
+
+	for _, expr := range cases {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Compile(%q) expected an error, got nil", expr)
+		}
+	}
+}
+
+func TestMatchesStringQuoting(t *testing.T) {
+	item := filterTestItem{Name: `quote " and backslash \`}
+
+	expr, err := Compile(`Name == "quote \" and backslash \\"`)
+	if err != nil {
+		t.Fatalf("Compile returned error: %v", err)
+	}
+	got, err := expr.Matches(item)
+	if err != nil {
+		t.Fatalf("Matches returned error: %v", err)
+	}
+	if !got {
+		t.Errorf("Matches() = false, want true for escaped quote/backslash round-trip")
+	}
+}
+
+func TestMatchesIsCaseFoldedOnStringComparisons(t *testing.T) {
+	item := filterTestItem{Type: "VMESS"}
+
+	expr, err := Compile(`type == "vmess"`)
+	if err != nil {
+		t.Fatalf("Compile returned error: %v", err)
+	}
+	got, err := expr.Matches(item)
+	if err != nil {
+		t.Fatalf("Matches returned error: %v", err)
+	}
+	if !got {
+		t.Errorf("Matches() = false, want true: field path and string literal comparisons should both case-fold")
+	}
+}
+
+func TestCompileRejectsOverlongPattern(t *testing.T) {
+	pattern := make([]byte, maxPatternLength+1)
+	for i := range pattern {
+		pattern[i] = 'a'
+	}
+
+	_, err := Compile(`Name matches "` + string(pattern) + `"`)
+	if err == nil {
+		t.Fatal("expected an error for a pattern longer than maxPatternLength")
+	}
+}
@@ -0,0 +1,163 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+type lexer struct {
+	src []rune
+	pos int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: []rune(src)}
+}
+
+func (l *lexer) peekRune() (rune, bool) {
+	if l.pos >= len(l.src) {
+		return 0, false
+	}
+	return l.src[l.pos], true
+}
+
+func (l *lexer) skipSpace() {
+	for {
+		r, ok := l.peekRune()
+		if !ok || !unicode.IsSpace(r) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// next returns the next token in the expression, or a tokEOF token once the
+// input is exhausted.
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+
+	start := l.pos
+	r, ok := l.peekRune()
+	if !ok {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	switch {
+	case r == '(':
+		l.pos++
+		return token{kind: tokLParen, pos: start}, nil
+	case r == ')':
+		l.pos++
+		return token{kind: tokRParen, pos: start}, nil
+	case r == ',':
+		l.pos++
+		return token{kind: tokComma, pos: start}, nil
+	case r == '"':
+		return l.lexString()
+	case r == '=' && l.at(l.pos+1) == '=':
+		l.pos += 2
+		return token{kind: tokEq, pos: start}, nil
+	case r == '!' && l.at(l.pos+1) == '=':
+		l.pos += 2
+		return token{kind: tokNeq, pos: start}, nil
+	case r == '<' && l.at(l.pos+1) == '=':
+		l.pos += 2
+		return token{kind: tokLte, pos: start}, nil
+	case r == '>' && l.at(l.pos+1) == '=':
+		l.pos += 2
+		return token{kind: tokGte, pos: start}, nil
+	case r == '<':
+		l.pos++
+		return token{kind: tokLt, pos: start}, nil
+	case r == '>':
+		l.pos++
+		return token{kind: tokGt, pos: start}, nil
+	case unicode.IsDigit(r):
+		return l.lexNumber()
+	case unicode.IsLetter(r) || r == '_':
+		return l.lexIdent()
+	default:
+		return token{}, &ParseError{Message: "unexpected character " + string(r), Pos: start}
+	}
+}
+
+// at returns the rune at i, or 0 past the end of input - used for 2-rune
+// lookahead without a bounds check at every call site.
+func (l *lexer) at(i int) rune {
+	if i >= len(l.src) {
+		return 0
+	}
+	return l.src[i]
+}
+
+func (l *lexer) lexString() (token, error) {
+	start := l.pos
+	l.pos++ // opening quote
+
+	var sb strings.Builder
+	for {
+		r, ok := l.peekRune()
+		if !ok {
+			return token{}, &ParseError{Message: "unterminated string literal", Pos: start}
+		}
+		l.pos++
+		if r == '"' {
+			return token{kind: tokString, text: sb.String(), pos: start}, nil
+		}
+		if r == '\\' {
+			esc, ok := l.peekRune()
+			if !ok {
+				return token{}, &ParseError{Message: "unterminated string literal", Pos: start}
+			}
+			l.pos++
+			sb.WriteRune(esc)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+}
+
+func (l *lexer) lexNumber() (token, error) {
+	start := l.pos
+	for {
+		r, ok := l.peekRune()
+		if !ok || !(unicode.IsDigit(r) || r == '.') {
+			break
+		}
+		l.pos++
+	}
+
+	// a trailing unit (ms, s, m, h, ...) turns this into a duration literal
+	// rather than a bare number, e.g. `Delay < 300ms`.
+	unitStart := l.pos
+	for {
+		r, ok := l.peekRune()
+		if !ok || !unicode.IsLetter(r) {
+			break
+		}
+		l.pos++
+	}
+
+	text := string(l.src[start:l.pos])
+	if l.pos > unitStart {
+		return token{kind: tokDuration, text: text, pos: start}, nil
+	}
+	return token{kind: tokNumber, text: text, pos: start}, nil
+}
+
+func (l *lexer) lexIdent() (token, error) {
+	start := l.pos
+	for {
+		r, ok := l.peekRune()
+		if !ok || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.') {
+			break
+		}
+		l.pos++
+	}
+
+	text := string(l.src[start:l.pos])
+	if kind, ok := keywords[strings.ToLower(text)]; ok {
+		return token{kind: kind, text: text, pos: start}, nil
+	}
+	return token{kind: tokIdent, text: text, pos: start}, nil
+}
@@ -0,0 +1,233 @@
+package filter
+
+import (
+	"strconv"
+	"time"
+)
+
+// maxPatternLength bounds the source a `matches` operator may compile into
+// a regexp, so a client can't hand the server an expensive pattern. Go's
+// RE2-based regexp engine is already immune to catastrophic backtracking,
+// this just keeps compile time and match cost proportional to something
+// sane regardless.
+const maxPatternLength = 256
+
+type parser struct {
+	lex *lexer
+	cur token
+}
+
+func newParser(src string) (*parser, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.cur = tok
+	return nil
+}
+
+func (p *parser) expect(kind tokenKind, what string) error {
+	if p.cur.kind != kind {
+		return &ParseError{Message: "expected " + what, Pos: p.cur.pos}
+	}
+	return p.advance()
+}
+
+// parse is the recursive-descent parser's entry point: or binds loosest, then and, then
+// unary not, then a single comparison or parenthesized sub-expression.
+func (p *parser) parse() (node, error) {
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.cur.kind != tokEOF {
+		return nil, &ParseError{Message: "unexpected trailing input", Pos: p.cur.pos}
+	}
+	return n, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.cur.kind == tokOr {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.cur.kind == tokAnd {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.cur.kind == tokNot {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{inner: inner}, nil
+	}
+
+	if p.cur.kind == tokLParen {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	if p.cur.kind != tokIdent {
+		return nil, &ParseError{Message: "expected a field name", Pos: p.cur.pos}
+	}
+	path := p.cur.text
+	opTok := token{}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	opTok = p.cur
+
+	switch opTok.kind {
+	case tokEq, tokNeq, tokLt, tokLte, tokGt, tokGte, tokContains:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		lit, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return &cmpNode{path: path, op: opTok.kind, lit: lit}, nil
+	case tokMatches:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		lit, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if lit.kind != tokString {
+			return nil, &ParseError{Message: "matches expects a string pattern", Pos: opTok.pos}
+		}
+		if len(lit.str) > maxPatternLength {
+			return nil, &ParseError{Message: "pattern too long", Pos: opTok.pos}
+		}
+		re, err := compileRegex(lit.str)
+		if err != nil {
+			return nil, &ParseError{Message: "invalid pattern: " + err.Error(), Pos: opTok.pos}
+		}
+		return &cmpNode{path: path, op: tokMatches, re: re}, nil
+	case tokIn:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list, err := p.parseLiteralList()
+		if err != nil {
+			return nil, err
+		}
+		return &cmpNode{path: path, op: tokIn, list: list}, nil
+	default:
+		return nil, &ParseError{Message: "expected a comparison operator", Pos: opTok.pos}
+	}
+}
+
+func (p *parser) parseLiteral() (literal, error) {
+	tok := p.cur
+	switch tok.kind {
+	case tokString:
+		if err := p.advance(); err != nil {
+			return literal{}, err
+		}
+		return literal{kind: tokString, str: tok.text}, nil
+	case tokNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return literal{}, &ParseError{Message: "invalid number " + tok.text, Pos: tok.pos}
+		}
+		if err := p.advance(); err != nil {
+			return literal{}, err
+		}
+		return literal{kind: tokNumber, num: n}, nil
+	case tokDuration:
+		d, err := time.ParseDuration(tok.text)
+		if err != nil {
+			return literal{}, &ParseError{Message: "invalid duration " + tok.text, Pos: tok.pos}
+		}
+		if err := p.advance(); err != nil {
+			return literal{}, err
+		}
+		return literal{kind: tokDuration, dur: d}, nil
+	default:
+		return literal{}, &ParseError{Message: "expected a value", Pos: tok.pos}
+	}
+}
+
+func (p *parser) parseLiteralList() ([]literal, error) {
+	if err := p.expect(tokLParen, "'('"); err != nil {
+		return nil, err
+	}
+
+	var list []literal
+	for {
+		lit, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, lit)
+
+		if p.cur.kind == tokComma {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		break
+	}
+
+	if err := p.expect(tokRParen, "')'"); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,55 @@
+package filter
+
+import "fmt"
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokDuration
+	tokAnd
+	tokOr
+	tokNot
+	tokIn
+	tokContains
+	tokMatches
+	tokEq
+	tokNeq
+	tokLt
+	tokLte
+	tokGt
+	tokGte
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+var keywords = map[string]tokenKind{
+	"and":      tokAnd,
+	"or":       tokOr,
+	"not":      tokNot,
+	"in":       tokIn,
+	"contains": tokContains,
+	"matches":  tokMatches,
+}
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// ParseError reports a lexing or parsing failure together with the
+// character (rune) offset it happened at, so the RESTful handler can point the caller at
+// exactly where their filter expression went wrong.
+type ParseError struct {
+	Message string
+	Pos     int
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("filter: %s (at position %d)", e.Message, e.Pos)
+}
@@ -0,0 +1,40 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/Dreamacro/clash/component/geodata"
+	"github.com/Dreamacro/clash/constant"
+	"github.com/Dreamacro/clash/hub/executor"
+	"github.com/Dreamacro/clash/log"
+)
+
+// geoDataRouter exposes a hot-reload hook for the geoip/geosite loaders
+// added alongside the mmdb and dat-remote backends: unlike configGeoRouter,
+// it doesn't re-download anything, it just forces the in-memory geo data
+// cache and dependent rule matchers to rebuild from what's already on disk.
+func geoDataRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Post("/reload", reloadGeoData)
+	return r
+}
+
+func reloadGeoData(w http.ResponseWriter, r *http.Request) {
+	log.Warnln("[RESTful API] reloading geo data...")
+
+	geodata.ClearCache()
+
+	cfg, err := executor.ParseWithPath(constant.Path.Config())
+	if err != nil {
+		log.Errorln("[RESTful API] reload geo data failed: %v", err)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, newError(err.Error()))
+		return
+	}
+
+	executor.ApplyConfig(cfg, false, "path")
+	render.NoContent(w, r)
+}
@@ -0,0 +1,53 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestMetricsRouterDisabledByDefault(t *testing.T) {
+	SetMetricsEnabled(false)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	metricsRouter().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 when disabled, got %d", rec.Code)
+	}
+}
+
+func TestMetricsRouterScrape(t *testing.T) {
+	SetMetricsEnabled(true)
+	defer SetMetricsEnabled(false)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	metricsRouter().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(rec.Body)
+	if err != nil {
+		t.Fatalf("scraped body did not parse as Prometheus text format: %v", err)
+	}
+
+	for _, name := range []string{
+		"clash_proxy_delay_ms",
+		"clash_proxy_alive",
+		"clash_connections_active",
+		"clash_dns_query_duration_seconds",
+		"clash_dns_cache_hits_total",
+		"clash_rule_match_total",
+	} {
+		if _, ok := families[name]; !ok {
+			t.Errorf("expected metric family %s in scrape output", name)
+		}
+	}
+}
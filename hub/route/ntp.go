@@ -0,0 +1,40 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/Dreamacro/clash/component/ntp"
+)
+
+// ntpRouter exposes the clock offset ntp.Now() is currently applying, so an
+// operator can confirm SNTP sync is actually landing before trusting it for
+// time-sensitive outbound auth (VMess/VLESS/REALITY).
+func ntpRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getNTPStatus)
+	return r
+}
+
+type ntpStatusSchema struct {
+	Synced     bool    `json:"synced"`
+	OffsetMs   float64 `json:"offsetMs"`
+	LastSyncAt *int64  `json:"lastSyncAt,omitempty"`
+}
+
+func getNTPStatus(w http.ResponseWriter, r *http.Request) {
+	offset, synced, lastSync := ntp.Offset()
+
+	resp := ntpStatusSchema{
+		Synced:   synced,
+		OffsetMs: float64(offset.Microseconds()) / 1000,
+	}
+	if synced {
+		unix := lastSync.Unix()
+		resp.LastSyncAt = &unix
+	}
+
+	render.JSON(w, r, resp)
+}
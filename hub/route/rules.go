@@ -0,0 +1,66 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/Dreamacro/clash/hub/route/filter"
+	"github.com/Dreamacro/clash/tunnel"
+)
+
+func ruleRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getRules)
+	return r
+}
+
+type ruleSchema struct {
+	Type    string `json:"type"`
+	Payload string `json:"payload"`
+	Proxy   string `json:"proxy"`
+}
+
+// getRules lists the active rule set, same as always, plus an optional
+// `?filter=` query parameter accepting the expression language documented
+// in hub/route/filter: e.g. `Type == "GeoSite" and Proxy matches "^JP-"`.
+func getRules(w http.ResponseWriter, r *http.Request) {
+	rawRules := tunnel.Rules()
+
+	rules := make([]ruleSchema, 0, len(rawRules))
+	for _, rule := range rawRules {
+		rules = append(rules, ruleSchema{
+			Type:    rule.RuleType().String(),
+			Payload: rule.Payload(),
+			Proxy:   rule.Adapter(),
+		})
+	}
+
+	if expr := r.URL.Query().Get("filter"); expr != "" {
+		compiled, err := filter.Compile(expr)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+
+		filtered := rules[:0]
+		for _, rule := range rules {
+			ok, err := compiled.Matches(rule)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, newError(err.Error()))
+				return
+			}
+			if ok {
+				filtered = append(filtered, rule)
+			}
+		}
+		rules = filtered
+	}
+
+	render.JSON(w, r, render.M{
+		"rules": rules,
+	})
+}
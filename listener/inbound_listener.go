@@ -0,0 +1,30 @@
+package proxy
+
+import (
+	"github.com/Dreamacro/clash/adapter/inbound"
+	C "github.com/Dreamacro/clash/constant"
+)
+
+// InboundListener is the common shape every concrete listener (http, socks,
+// mixed, redir, tproxy, mitm, ...) satisfies, so a future multi-instance
+// manager can start/diff/stop them by name instead of each protocol owning
+// its own global singleton and ReCreateXxx function.
+//
+// This interface is the first step of that refactor: the singletons and
+// ReCreateHTTP/Socks/Redir/TProxy/Mixed/Mitm functions further down this
+// package still own the actual listener packages (listener/http,
+// listener/socks, ...), which aren't part of this checkout, so they aren't
+// rewired onto InboundListener yet.
+type InboundListener interface {
+	// Name identifies this listener instance for config-reload reconciliation
+	// and for tagging metadata.InName on connections it accepts.
+	Name() string
+	// Listen starts accepting connections, dispatching them into tcpIn/udpIn
+	// the same way ReCreateHTTP/Socks/... do today.
+	Listen(tcpIn chan<- C.ConnContext, udpIn chan<- *inbound.PacketAdapter) error
+	Close() error
+	// RawAddress is the address as configured (e.g. may be a wildcard host).
+	RawAddress() string
+	// Address is the address actually bound to, with the wildcard resolved.
+	Address() string
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/Dreamacro/clash/adapter/outbound"
 	"github.com/Dreamacro/clash/common/cert"
 	"github.com/Dreamacro/clash/component/ebpf"
+	"github.com/Dreamacro/clash/component/unixsocket"
 	"github.com/Dreamacro/clash/config"
 	C "github.com/Dreamacro/clash/constant"
 	"github.com/Dreamacro/clash/listener/autoredir"
@@ -51,6 +52,7 @@ var (
 	mixedUDPLister    *socks.UDPListener
 	tunStackListener  ipstack.Stack
 	mitmListener      *mitm.Listener
+	mitmHandler       = rewrites.NewRewriteHandler()
 	tcProgram         *ebpf.TcEBpfProgram
 	autoRedirListener *autoredir.Listener
 	autoRedirProgram  *ebpf.TcEBpfProgram
@@ -396,7 +398,7 @@ func ReCreateTun(tunConf *config.Tun, tcpIn chan<- C.ConnContext, udpIn chan<- *
 	}
 }
 
-func ReCreateMitm(port int, tcpIn chan<- C.ConnContext) {
+func ReCreateMitm(port int, rewriteRules []C.Rewrite, tcpIn chan<- C.ConnContext) {
 	mitmMux.Lock()
 	defer mitmMux.Unlock()
 
@@ -411,6 +413,10 @@ func ReCreateMitm(port int, tcpIn chan<- C.ConnContext) {
 
 	if mitmListener != nil {
 		if mitmListener.RawAddress() == addr {
+			// Listener and root CA stay up; only the rule set changes, so
+			// hot-swap it through the handler instead of the old
+			// close+recreate+regenerate-cert dance.
+			mitmHandler.Update(rewriteRules)
 			return
 		}
 		_ = mitmListener.Close()
@@ -455,11 +461,13 @@ func ReCreateMitm(port int, tcpIn chan<- C.ConnContext) {
 	certOption.SetValidity(time.Hour * 24 * 365 * 2) // 2 years
 	certOption.SetOrganization("Clash ManInTheMiddle Proxy Services")
 
+	mitmHandler.Update(rewriteRules)
+
 	opt := &mitm.Option{
 		Addr:       addr,
 		ApiHost:    "mitm.clash",
 		CertConfig: certOption,
-		Handler:    &rewrites.RewriteHandler{},
+		Handler:    mitmHandler,
 	}
 
 	mitmListener, err = mitm.New(opt, tcpIn)
@@ -564,45 +572,36 @@ func GetPorts() *Ports {
 	ports := &Ports{}
 
 	if httpListener != nil {
-		_, portStr, _ := net.SplitHostPort(httpListener.Address())
-		port, _ := strconv.Atoi(portStr)
-		ports.Port = port
+		ports.Port = portOf(httpListener.Address())
 	}
 
 	if socksListener != nil {
-		_, portStr, _ := net.SplitHostPort(socksListener.Address())
-		port, _ := strconv.Atoi(portStr)
-		ports.SocksPort = port
+		ports.SocksPort = portOf(socksListener.Address())
 	}
 
 	if redirListener != nil {
-		_, portStr, _ := net.SplitHostPort(redirListener.Address())
-		port, _ := strconv.Atoi(portStr)
-		ports.RedirPort = port
+		ports.RedirPort = portOf(redirListener.Address())
 	}
 
 	if tproxyListener != nil {
-		_, portStr, _ := net.SplitHostPort(tproxyListener.Address())
-		port, _ := strconv.Atoi(portStr)
-		ports.TProxyPort = port
+		ports.TProxyPort = portOf(tproxyListener.Address())
 	}
 
 	if mixedListener != nil {
-		_, portStr, _ := net.SplitHostPort(mixedListener.Address())
-		port, _ := strconv.Atoi(portStr)
-		ports.MixedPort = port
+		ports.MixedPort = portOf(mixedListener.Address())
 	}
 
 	if mitmListener != nil {
-		_, portStr, _ := net.SplitHostPort(mitmListener.Address())
-		port, _ := strconv.Atoi(portStr)
-		ports.MitmPort = port
+		ports.MitmPort = portOf(mitmListener.Address())
 	}
 
 	return ports
 }
 
 func portIsZero(addr string) bool {
+	if unixsocket.IsUnixAddr(addr) {
+		return false
+	}
 	_, port, err := net.SplitHostPort(addr)
 	if port == "0" || port == "" || err != nil {
 		return true
@@ -621,6 +620,20 @@ func genAddr(host string, port int, allowLan bool) string {
 	return fmt.Sprintf("127.0.0.1:%d", port)
 }
 
+// portOf extracts the numeric port GetPorts reports for addr, or 0 for a
+// unix:// address, which has no port to report.
+func portOf(addr string) int {
+	if unixsocket.IsUnixAddr(addr) {
+		return 0
+	}
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0
+	}
+	port, _ := strconv.Atoi(portStr)
+	return port
+}
+
 func hasTunConfigChange(tunConf *config.Tun) bool {
 	if lastTunConf == nil {
 		return true
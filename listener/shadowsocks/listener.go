@@ -0,0 +1,85 @@
+// Package shadowsocks lets Clash act as a Shadowsocks server for downstream
+// clients, producing the same C.ConnContext/C.PacketAdapter the http/socks/
+// mixed listeners do so rule matching, DNS hijack and outbound dispatch all
+// work unchanged.
+package shadowsocks
+
+import (
+	"net"
+
+	"github.com/Dreamacro/clash/adapter/inbound"
+	C "github.com/Dreamacro/clash/constant"
+	icontext "github.com/Dreamacro/clash/context"
+	"github.com/Dreamacro/clash/log"
+	"github.com/Dreamacro/clash/transport/ss"
+)
+
+// Listener accepts Shadowsocks TCP connections on a single address/cipher/
+// password combination.
+type Listener struct {
+	listener net.Listener
+	addr     string
+	cipher   *ss.Cipher
+	closed   bool
+}
+
+// RawAddress is the address Listener was constructed with.
+func (l *Listener) RawAddress() string {
+	return l.addr
+}
+
+// Address is the address actually bound to.
+func (l *Listener) Address() string {
+	return l.listener.Addr().String()
+}
+
+// Close stops accepting new connections.
+func (l *Listener) Close() error {
+	l.closed = true
+	return l.listener.Close()
+}
+
+// New starts a Shadowsocks TCP server on addr, decrypting with cipherName/
+// password per SIP004 and forwarding each accepted connection's target
+// request into tcpIn the same way the other TCP listeners do.
+func New(addr, cipherName, password string, tcpIn chan<- C.ConnContext) (*Listener, error) {
+	c, err := ss.NewCipher(cipherName, password)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	sl := &Listener{listener: l, addr: addr, cipher: c}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				if sl.closed {
+					break
+				}
+				continue
+			}
+			go handleConn(conn, c, tcpIn)
+		}
+	}()
+
+	return sl, nil
+}
+
+func handleConn(conn net.Conn, c *ss.Cipher, tcpIn chan<- C.ConnContext) {
+	sc := ss.NewStreamConn(conn, c)
+
+	target, err := ss.ReadAddr(sc)
+	if err != nil {
+		log.Debugln("[Shadowsocks] read target: %s", err.Error())
+		_ = conn.Close()
+		return
+	}
+
+	tcpIn <- icontext.NewConnContext(sc, inbound.NewSocket(target, sc, C.SHADOWSOCKS))
+}
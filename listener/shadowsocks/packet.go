@@ -0,0 +1,42 @@
+package shadowsocks
+
+import (
+	"net"
+
+	"github.com/Dreamacro/clash/transport/ss"
+)
+
+// packet implements C.UDPPacket for a single decrypted Shadowsocks
+// datagram, so tunnel can reply through the same cipher/source address it
+// arrived on.
+type packet struct {
+	pc      net.PacketConn
+	rAddr   net.Addr
+	cipher  *ss.Cipher
+	payload []byte
+}
+
+func (p *packet) Data() []byte {
+	return p.payload
+}
+
+// WriteBack encrypts b as a fresh datagram back to the client that sent
+// this packet, or to addr if the caller wants to override it.
+func (p *packet) WriteBack(b []byte, addr net.Addr) (n int, err error) {
+	if addr == nil {
+		addr = p.rAddr
+	}
+
+	sealed, err := ss.EncryptPacket(p.cipher, b)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.pc.WriteTo(sealed, addr)
+}
+
+func (p *packet) Drop() {}
+
+func (p *packet) LocalAddr() net.Addr {
+	return p.pc.LocalAddr()
+}
@@ -0,0 +1,83 @@
+package shadowsocks
+
+import (
+	"net"
+
+	"github.com/Dreamacro/clash/adapter/inbound"
+	C "github.com/Dreamacro/clash/constant"
+	"github.com/Dreamacro/clash/log"
+	"github.com/Dreamacro/clash/transport/ss"
+)
+
+// UDPListener relays Shadowsocks UDP datagrams the same way Listener relays
+// TCP connections.
+type UDPListener struct {
+	packetConn net.PacketConn
+	addr       string
+	cipher     *ss.Cipher
+	closed     bool
+}
+
+func (l *UDPListener) RawAddress() string {
+	return l.addr
+}
+
+func (l *UDPListener) Address() string {
+	return l.packetConn.LocalAddr().String()
+}
+
+func (l *UDPListener) Close() error {
+	l.closed = true
+	return l.packetConn.Close()
+}
+
+// NewUDP starts a Shadowsocks UDP relay on addr, decrypting each datagram
+// per SIP004 and forwarding its target request into udpIn.
+func NewUDP(addr, cipherName, password string, udpIn chan<- *inbound.PacketAdapter) (*UDPListener, error) {
+	c, err := ss.NewCipher(cipherName, password)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ul := &UDPListener{packetConn: pc, addr: addr, cipher: c}
+
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, rAddr, err := pc.ReadFrom(buf)
+			if err != nil {
+				if ul.closed {
+					break
+				}
+				continue
+			}
+
+			payload, err := ss.DecryptPacket(c, buf[:n])
+			if err != nil {
+				log.Debugln("[Shadowsocks] decrypt UDP packet from %s: %s", rAddr, err.Error())
+				continue
+			}
+
+			target, rest, err := ss.SplitAddr(payload)
+			if err != nil {
+				log.Debugln("[Shadowsocks] read UDP target from %s: %s", rAddr, err.Error())
+				continue
+			}
+
+			pkt := &packet{
+				pc:      pc,
+				rAddr:   rAddr,
+				cipher:  c,
+				payload: rest,
+			}
+			udpIn <- inbound.NewPacket(target, pkt, C.SHADOWSOCKS)
+		}
+	}()
+
+	return ul, nil
+}
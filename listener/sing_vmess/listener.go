@@ -0,0 +1,80 @@
+// Package sing_vmess lets Clash act as a VMess server for downstream
+// clients. The legacy VMess AEAD request header (timestamp + FNV-1a auth +
+// AES-128-CFB-wrapped command/uuid/alterId/cipher/address) isn't
+// implemented in this tree yet, so New accepts configuration and starts
+// accepting connections, but handshake parsing currently always fails with
+// errNeedsVMessCodec - wiring real clients through is future work once that
+// codec lands, most likely alongside adapter/outbound's own VMess client.
+package sing_vmess
+
+import (
+	"errors"
+	"net"
+
+	C "github.com/Dreamacro/clash/constant"
+	"github.com/Dreamacro/clash/log"
+)
+
+var errNeedsVMessCodec = errors.New("sing_vmess: legacy VMess AEAD request codec is not implemented in this build")
+
+// User is one entry of the `users:` list a VMess inbound accepts.
+type User struct {
+	UUID    string
+	AlterID int
+	Cipher  string
+}
+
+// Listener accepts VMess TCP connections for the configured Users.
+type Listener struct {
+	listener net.Listener
+	addr     string
+	users    []User
+	closed   bool
+}
+
+func (l *Listener) RawAddress() string {
+	return l.addr
+}
+
+func (l *Listener) Address() string {
+	return l.listener.Addr().String()
+}
+
+func (l *Listener) Close() error {
+	l.closed = true
+	return l.listener.Close()
+}
+
+// New starts a VMess TCP server on addr for users. See the package doc:
+// accepted connections presently always fail their handshake with
+// errNeedsVMessCodec.
+func New(addr string, users []User, tcpIn chan<- C.ConnContext) (*Listener, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Warnln("[VMess] listening on %s: %s - every inbound connection will be rejected", addr, errNeedsVMessCodec)
+
+	vl := &Listener{listener: l, addr: addr, users: users}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				if vl.closed {
+					break
+				}
+				continue
+			}
+			go handleConn(conn)
+		}
+	}()
+
+	return vl, nil
+}
+
+func handleConn(conn net.Conn) {
+	defer conn.Close()
+	log.Debugln("[VMess] rejecting inbound connection from %s: %s", conn.RemoteAddr(), errNeedsVMessCodec)
+}
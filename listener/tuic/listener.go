@@ -0,0 +1,72 @@
+// Package tuic lets Clash act as a TUIC server for downstream clients.
+// TUIC is QUIC-based end to end, and this tree doesn't vendor quic-go (the
+// same gap dns/doq.go documents for DNS-over-QUIC), so New accepts
+// configuration but always returns errNeedsQUIC instead of actually
+// listening.
+package tuic
+
+import (
+	"errors"
+)
+
+var errNeedsQUIC = errors.New("tuic: requires a QUIC transport (quic-go) not present in this build")
+
+// CongestionControl selects the QUIC congestion-control algorithm a TUIC
+// server negotiates with clients.
+type CongestionControl int
+
+const (
+	CongestionControlCubic CongestionControl = iota
+	CongestionControlBBR
+	CongestionControlNewReno
+)
+
+// UDPRelayMode selects how TUIC relays UDP: as its own "native" packets, or
+// wrapped back into QUIC datagrams/streams ("quic").
+type UDPRelayMode int
+
+const (
+	UDPRelayModeNative UDPRelayMode = iota
+	UDPRelayModeQuic
+)
+
+// User is one entry of the `users:` list a TUIC inbound authenticates
+// clients against.
+type User struct {
+	UUID     string
+	Password string
+}
+
+// Config is a TUIC inbound's full configuration, parsed from the
+// `inbounds:` entry's YAML.
+type Config struct {
+	Users             []User
+	CertificatePath   string
+	KeyPath           string
+	CongestionControl CongestionControl
+	UDPRelayMode      UDPRelayMode
+}
+
+// Listener would accept TUIC connections on a QUIC transport; see the
+// package doc for why New always fails in this build.
+type Listener struct {
+	addr string
+}
+
+func (l *Listener) RawAddress() string {
+	return l.addr
+}
+
+func (l *Listener) Address() string {
+	return l.addr
+}
+
+func (l *Listener) Close() error {
+	return nil
+}
+
+// New always returns errNeedsQUIC without examining cfg: there is no QUIC
+// transport to listen on in this build.
+func New(addr string, cfg Config) (*Listener, error) {
+	return nil, errNeedsQUIC
+}
@@ -0,0 +1,55 @@
+// Package device abstracts the platform-specific TUN/fd backends
+// (fdbased on Linux, wireguard-go's tun.Device elsewhere) behind the
+// common shape the rest of the tun listener needs.
+package device
+
+import "io"
+
+// Device is the minimal shape every backend implements, whether or not it
+// ever hands raw packets to this package directly - fdbased's FD, for
+// instance, wires straight into gVisor's stack.LinkEndpoint and never
+// implements IODevice below.
+type Device interface {
+	io.Closer
+
+	Name() string
+	MTU() uint32
+
+	// UseEndpoint attaches the backend to a stack.LinkEndpoint so the
+	// gVisor netstack can drive it; UseIOBased is the lighter-weight path
+	// some backends use instead (or as well).
+	UseEndpoint() error
+	UseIOBased() error
+}
+
+// IODevice is implemented by Device backends whose packets are read and
+// written one at a time through plain byte slices - the shape
+// iobased.Endpoint wraps in a stack.LinkEndpoint.
+type IODevice interface {
+	Device
+
+	Read(packet []byte) (int, error)
+	Write(packet []byte) (int, error)
+}
+
+// BatchDevice is implemented by an IODevice backend that can also read or
+// write several packets in a single call - wireguard-go's vectorized
+// tun.Device API on non-Linux platforms, with TCP GRO coalescing on
+// receive and GSO segmentation on transmit.
+//
+// TODO(chunk4-4): iobased.Endpoint doesn't type-assert for this yet, so
+// ReadPackets/WritePackets aren't on the real packet path - only exercised
+// today by test's synthetic loopbackDevice benchmark. Wiring iobased.Endpoint
+// to batch through this interface when a backend implements it is the
+// follow-up that makes the feature this describes actually real.
+type BatchDevice interface {
+	IODevice
+
+	// ReadPackets fills as many of bufs as are available in one call,
+	// recording each packet's length in the matching sizes entry, and
+	// returns how many were filled.
+	ReadPackets(bufs [][]byte, sizes []int) (n int, err error)
+	// WritePackets writes every one of bufs as a separate packet in one
+	// call.
+	WritePackets(bufs [][]byte) (n int, err error)
+}
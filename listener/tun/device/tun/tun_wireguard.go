@@ -7,6 +7,7 @@ import (
 	"os"
 	"runtime"
 
+	"golang.zx2c4.com/wireguard/conn"
 	"golang.zx2c4.com/wireguard/tun"
 
 	"github.com/Dreamacro/clash/listener/tun/device"
@@ -22,6 +23,13 @@ type TUN struct {
 	offset int
 
 	cache []byte
+
+	// cacheBufs backs ReadPackets/WritePackets the same way cache backs
+	// Read/Write: one mtu+offset scratch buffer per batch slot, so the
+	// offset's header room can be prepended/stripped without the caller's
+	// own buffers needing to know about it. Grown lazily up to
+	// conn.IdealBatchSize, wireguard-go's own vectorized I/O batch size.
+	cacheBufs [][]byte
 }
 
 func Open(name string, mtu uint32) (_ device.Device, err error) {
@@ -97,6 +105,60 @@ func (t *TUN) Write(packet []byte) (int, error) {
 	return n - t.offset, err
 }
 
+// ReadPackets fills bufs in a single vectorized call via wireguard-go's
+// batched tun.Device.Read, so a GRO-coalesced read that would otherwise take
+// len(bufs) syscalls takes one. Callers should size bufs/sizes to
+// conn.IdealBatchSize to match what the underlying device actually batches.
+func (t *TUN) ReadPackets(bufs [][]byte, sizes []int) (int, error) {
+	if t.offset == 0 {
+		return t.nt.Read(bufs, sizes, 0)
+	}
+
+	t.growCacheBufs(len(bufs))
+
+	n, err := t.nt.Read(t.cacheBufs[:len(bufs)], sizes, t.offset)
+	for i := 0; i < n; i++ {
+		sizes[i] = copy(bufs[i], t.cacheBufs[i][t.offset:t.offset+sizes[i]])
+	}
+	return n, err
+}
+
+// WritePackets is the GSO counterpart of ReadPackets: it hands every one of
+// bufs to the kernel/wintun in a single wireguard-go tun.Device.Write call.
+func (t *TUN) WritePackets(bufs [][]byte) (int, error) {
+	if t.offset == 0 {
+		return t.nt.Write(bufs, 0)
+	}
+
+	t.growCacheBufs(len(bufs))
+
+	// out is its own slice so cacheBufs keeps its full mtu+offset lengths
+	// for the next ReadPackets.
+	out := make([][]byte, len(bufs))
+	for i, packet := range bufs {
+		out[i] = append(t.cacheBufs[i][:t.offset], packet...)
+	}
+
+	return t.nt.Write(out, t.offset)
+}
+
+// growCacheBufs grows cacheBufs to hold at least n mtu+offset scratch
+// buffers, reusing whatever's already allocated across calls.
+func (t *TUN) growCacheBufs(n int) {
+	for len(t.cacheBufs) < n {
+		t.cacheBufs = append(t.cacheBufs, make([]byte, int(t.mtu)+t.offset))
+	}
+}
+
+// BatchSize is the number of packets ReadPackets/WritePackets can move in
+// one call - wireguard-go's own recommended batch size, the same value its
+// multi-queue UDP bind code sizes its batches to.
+func (t *TUN) BatchSize() int {
+	return conn.IdealBatchSize
+}
+
+var _ device.BatchDevice = (*TUN)(nil)
+
 func (t *TUN) Close() error {
 	defer func(ep *iobased.Endpoint) {
 		if ep != nil {
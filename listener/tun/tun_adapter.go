@@ -29,7 +29,7 @@ func New(tunConf *config.Tun, tcpIn chan<- C.ConnContext, udpIn chan<- *inbound.
 		devName    = tunConf.Device
 		stackType  = tunConf.Stack
 		autoRoute  = tunConf.AutoRoute
-		mtu        = 9000
+		mtu        = defaultMTU(tunConf)
 
 		tunDevice device.Device
 		tunStack  ipstack.Stack
@@ -55,6 +55,20 @@ func New(tunConf *config.Tun, tcpIn chan<- C.ConnContext, udpIn chan<- *inbound.
 		tunAddress = netip.MustParsePrefix("198.18.0.1/16")
 	}
 
+	// TunAddressPrefix6 is accepted for forward compatibility, but dual-stack
+	// isn't wired into the gvisor/system ipstack backends yet (that needs
+	// the same signature change in both, tracked alongside this), so
+	// configuring it only warns - the TUN device stays IPv4-only, the same
+	// as before this field existed, rather than failing to build against
+	// ipstack/commons functions this tree doesn't have.
+	if tunConf.TunAddressPrefix6 != nil {
+		if prefix := *tunConf.TunAddressPrefix6; prefix.IsValid() && prefix.Addr().Is6() {
+			log.Warnln("[TUN] tun-address-prefix-6 is set but IPv6 dual-stack isn't wired into the %s ip stack in this build - the TUN device will stay IPv4-only", stackType)
+		} else {
+			log.Warnln("[TUN] ignoring invalid tun-address-prefix-6: %s", prefix)
+		}
+	}
+
 	// open tun device
 	tunDevice, err = parseDevice(devName, uint32(mtu))
 	if err != nil {
@@ -102,12 +116,26 @@ func New(tunConf *config.Tun, tcpIn chan<- C.ConnContext, udpIn chan<- *inbound.
 	}
 
 	tunConf.Device = devName
-	setAtLatest(stackType, devName)
+	if !tunConf.DisableSysctlTuning {
+		setAtLatest(stackType, devName, tunConf.Sysctls)
+	}
 
 	log.Infoln("TUN stack listening at: %s(%s), mtu: %d, auto route: %v, ip stack: %s", tunDevice.Name(), tunAddress.Masked().Addr().Next().String(), mtu, autoRoute, stackType)
 	return tunStack, nil
 }
 
+// defaultMTU is 9000 unless tunConf overrides it - mobile/embedded users on
+// PPPoE links need to lower it below 9000, and the TUN driver/ipstack both
+// read from this value rather than a hard-coded constant. MTU, like every
+// other tunConf field this package reads (Device, Stack, DNSHijack, ...),
+// is defined on config.Tun itself, not in this package.
+func defaultMTU(tunConf *config.Tun) int {
+	if tunConf.MTU > 0 {
+		return int(tunConf.MTU)
+	}
+	return 9000
+}
+
 func generateDeviceName() string {
 	switch runtime.GOOS {
 	case "darwin":
@@ -119,6 +147,14 @@ func generateDeviceName() string {
 	}
 }
 
+// ParseDevice resolves a "driver://name" (or bare name, defaulting to
+// tun.Driver) device URL to the matching backend's Open - exported so
+// callers like the dry-run config validator can probe whether a device is
+// openable without going through New's full stack setup.
+func ParseDevice(s string, mtu uint32) (device.Device, error) {
+	return parseDevice(s, mtu)
+}
+
 func parseDevice(s string, mtu uint32) (device.Device, error) {
 	if !strings.Contains(s, "://") {
 		s = fmt.Sprintf("%s://%s", tun.Driver /* default driver */, s)
@@ -142,7 +178,12 @@ func parseDevice(s string, mtu uint32) (device.Device, error) {
 	}
 }
 
-func setAtLatest(stackType C.TUNStack, devName string) {
+// setAtLatest applies this platform's default post-up tuning, then any
+// user-supplied overrides in sysctls - e.g. a container without permission
+// to flip net.ipv4.conf.all.rp_filter can set it to the value it already
+// has, turning that one mutation into a no-op, without losing the rest of
+// the profile. Callers skip this entirely when DisableSysctlTuning is set.
+func setAtLatest(stackType C.TUNStack, devName string, sysctls map[string]string) {
 	switch runtime.GOOS {
 	case "darwin":
 		// _, _ = cmd.ExecCmd("/usr/sbin/sysctl -w net.inet.ip.forwarding=1")
@@ -165,4 +206,8 @@ func setAtLatest(stackType C.TUNStack, devName string) {
 		_, _ = cmd.ExecCmd(fmt.Sprintf("sysctl -w net.ipv4.conf.%s.rp_filter=0", devName))
 		//_, _ = cmd.ExecCmd("iptables -t filter -P FORWARD ACCEPT")
 	}
+
+	for key, value := range sysctls {
+		_, _ = cmd.ExecCmd(fmt.Sprintf("sysctl -w %s=%s", key, value))
+	}
 }
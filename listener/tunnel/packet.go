@@ -0,0 +1,27 @@
+package tunnel
+
+import "net"
+
+// packet implements C.UDPPacket for one datagram received by UDPListener.
+type packet struct {
+	pc      net.PacketConn
+	rAddr   net.Addr
+	payload []byte
+}
+
+func (p *packet) Data() []byte {
+	return p.payload
+}
+
+func (p *packet) WriteBack(b []byte, addr net.Addr) (n int, err error) {
+	if addr == nil {
+		addr = p.rAddr
+	}
+	return p.pc.WriteTo(b, addr)
+}
+
+func (p *packet) Drop() {}
+
+func (p *packet) LocalAddr() net.Addr {
+	return p.pc.LocalAddr()
+}
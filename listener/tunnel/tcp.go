@@ -0,0 +1,79 @@
+// Package tunnel is a static port-forwarder inbound: every connection
+// accepted on a configured address is given a synthesized target (and,
+// optionally, a proxy that bypasses rule matching entirely), the same way a
+// firewall's DNAT rule would, but routed through Clash's own outbound
+// selection.
+package tunnel
+
+import (
+	"net"
+
+	"github.com/Dreamacro/clash/adapter/inbound"
+	C "github.com/Dreamacro/clash/constant"
+	icontext "github.com/Dreamacro/clash/context"
+	"github.com/Dreamacro/clash/transport/socks5"
+)
+
+// Listener relays every TCP connection it accepts to a single static
+// target.
+type Listener struct {
+	listener net.Listener
+	addr     string
+	target   string
+	proxy    string
+	closed   bool
+}
+
+func (l *Listener) RawAddress() string {
+	return l.addr
+}
+
+func (l *Listener) Address() string {
+	return l.listener.Addr().String()
+}
+
+func (l *Listener) Close() error {
+	l.closed = true
+	return l.listener.Close()
+}
+
+// New starts a TCP listener on addr that forwards every accepted
+// connection to target, through proxy if non-empty.
+func New(addr, target, proxy string, tcpIn chan<- C.ConnContext) (*Listener, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	tl := &Listener{listener: l, addr: addr, target: target, proxy: proxy}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				if tl.closed {
+					break
+				}
+				continue
+			}
+			go handleConn(conn, target, proxy, tcpIn)
+		}
+	}()
+
+	return tl, nil
+}
+
+func handleConn(conn net.Conn, target, proxy string, tcpIn chan<- C.ConnContext) {
+	addr, err := socks5.ParseAddr(target)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	additions := []inbound.Addition{inbound.WithInName("tunnel")}
+	if proxy != "" {
+		additions = append(additions, inbound.WithSpecialProxy(proxy))
+	}
+
+	tcpIn <- icontext.NewConnContext(conn, inbound.NewSocket(addr, conn, C.TUNNEL, additions...))
+}
@@ -0,0 +1,74 @@
+package tunnel
+
+import (
+	"net"
+
+	"github.com/Dreamacro/clash/adapter/inbound"
+	C "github.com/Dreamacro/clash/constant"
+	"github.com/Dreamacro/clash/transport/socks5"
+)
+
+// UDPListener relays every datagram it receives to a single static target.
+type UDPListener struct {
+	packetConn net.PacketConn
+	addr       string
+	target     string
+	proxy      string
+	closed     bool
+}
+
+func (l *UDPListener) RawAddress() string {
+	return l.addr
+}
+
+func (l *UDPListener) Address() string {
+	return l.packetConn.LocalAddr().String()
+}
+
+func (l *UDPListener) Close() error {
+	l.closed = true
+	return l.packetConn.Close()
+}
+
+// NewUDP starts a UDP relay on addr that forwards every datagram to target,
+// through proxy if non-empty.
+func NewUDP(addr, target, proxy string, udpIn chan<- *inbound.PacketAdapter) (*UDPListener, error) {
+	targetAddr, err := socks5.ParseAddr(target)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ul := &UDPListener{packetConn: pc, addr: addr, target: target, proxy: proxy}
+
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, rAddr, err := pc.ReadFrom(buf)
+			if err != nil {
+				if ul.closed {
+					break
+				}
+				continue
+			}
+
+			payload := make([]byte, n)
+			copy(payload, buf[:n])
+
+			pkt := &packet{pc: pc, rAddr: rAddr, payload: payload}
+
+			additions := []inbound.Addition{inbound.WithInName("tunnel")}
+			if proxy != "" {
+				additions = append(additions, inbound.WithSpecialProxy(proxy))
+			}
+
+			udpIn <- inbound.NewPacket(targetAddr, pkt, C.TUNNEL, additions...)
+		}
+	}()
+
+	return ul, nil
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Dreamacro/clash/adapter/inbound"
+	C "github.com/Dreamacro/clash/constant"
+	tunnelinbound "github.com/Dreamacro/clash/listener/tunnel"
+	"github.com/Dreamacro/clash/log"
+)
+
+// TunnelConf is one entry of the `tunnels:` config section: a static
+// port-forwarder that relays a listen address to target, optionally
+// through a specific proxy that bypasses rule matching.
+type TunnelConf struct {
+	Network []string `yaml:"network"`
+	Address string   `yaml:"address"`
+	Target  string   `yaml:"target"`
+	Proxy   string   `yaml:"proxy"`
+}
+
+type tunnelListener interface {
+	Close() error
+}
+
+type runningTunnel struct {
+	network  string
+	address  string
+	target   string
+	listener tunnelListener
+}
+
+var (
+	tunnelMux       sync.Mutex
+	tunnelListeners = map[string]runningTunnel{}
+)
+
+// tunnelKey identifies a running tunnel the same way RawAddress identifies
+// a singleton listener, so ReCreateTunnels can diff the running set against
+// conf instead of tearing everything down on every reload.
+func tunnelKey(network, address, target string) string {
+	return fmt.Sprintf("%s|%s|%s", network, address, target)
+}
+
+// ReCreateTunnels reconciles the running static tunnel-forwarders against
+// conf: entries no longer present are closed, entries already running
+// untouched, and new entries started.
+func ReCreateTunnels(conf []TunnelConf, tcpIn chan<- C.ConnContext, udpIn chan<- *inbound.PacketAdapter) {
+	tunnelMux.Lock()
+	defer tunnelMux.Unlock()
+
+	wantedKeys := map[string]struct{}{}
+	for _, t := range conf {
+		for _, network := range t.Network {
+			wantedKeys[tunnelKey(network, t.Address, t.Target)] = struct{}{}
+		}
+	}
+
+	for key, running := range tunnelListeners {
+		if _, ok := wantedKeys[key]; !ok {
+			running.listener.Close()
+			delete(tunnelListeners, key)
+		}
+	}
+
+	for _, t := range conf {
+		for _, network := range t.Network {
+			key := tunnelKey(network, t.Address, t.Target)
+			if _, ok := tunnelListeners[key]; ok {
+				continue
+			}
+
+			var l tunnelListener
+			var err error
+			switch network {
+			case "tcp":
+				l, err = tunnelinbound.New(t.Address, t.Target, t.Proxy, tcpIn)
+			case "udp":
+				l, err = tunnelinbound.NewUDP(t.Address, t.Target, t.Proxy, udpIn)
+			default:
+				log.Errorln("[Tunnel] unknown network %q for %s -> %s", network, t.Address, t.Target)
+				continue
+			}
+			if err != nil {
+				log.Errorln("[Tunnel] start %s %s -> %s: %s", network, t.Address, t.Target, err.Error())
+				continue
+			}
+
+			tunnelListeners[key] = runningTunnel{network: network, address: t.Address, target: t.Target, listener: l}
+			log.Infoln("[Tunnel] %s %s proxying to %s", network, t.Address, t.Target)
+		}
+	}
+}
@@ -87,3 +87,23 @@ func NewRewriteRule(urlRegx *regexp.Regexp, ruleType C.RewriteType, ruleRegx *re
 }
 
 var _ C.Rewrite = (*RewriteRule)(nil)
+
+// RewriteHandler serves mitm.Option.Handler. Its RuleStore is created once
+// alongside the MITM listener and subsequently mutated in place by Update,
+// so a config reload that only changes `rewrite`/`script` rules no longer
+// has to tear down and recreate the listener (and regenerate the leaf
+// cert a browser may have already pinned) just to pick up new rules.
+type RewriteHandler struct {
+	Store *RuleStore
+}
+
+// NewRewriteHandler returns a RewriteHandler with an empty, ready-to-Update
+// RuleStore.
+func NewRewriteHandler() *RewriteHandler {
+	return &RewriteHandler{Store: NewRuleStore()}
+}
+
+// Update replaces h's rule set in place.
+func (h *RewriteHandler) Update(rules []C.Rewrite) {
+	h.Store.Update(rules)
+}
@@ -0,0 +1,109 @@
+package rewrites
+
+import (
+	"strings"
+	"sync"
+
+	C "github.com/Dreamacro/clash/constant"
+)
+
+// RuleStore indexes rewrite rules by the host they apply to, so a lookup on
+// the hot MITM request path only scans the rules registered for that host
+// instead of every configured rule.
+type RuleStore struct {
+	mux   sync.RWMutex
+	byID  map[string]C.Rewrite
+	byKey map[string][]C.Rewrite
+}
+
+// NewRuleStore returns an empty RuleStore ready for Update.
+func NewRuleStore() *RuleStore {
+	return &RuleStore{
+		byID:  map[string]C.Rewrite{},
+		byKey: map[string][]C.Rewrite{},
+	}
+}
+
+// ruleKey groups a rule under the host its URLRegx is anchored to,
+// falling back to matching it against every host if the pattern has no
+// recognizable literal host prefix to index on.
+func ruleKey(r C.Rewrite) string {
+	if host := literalHost(r.URLRegx().String()); host != "" {
+		return host
+	}
+	return "*"
+}
+
+// Update replaces the store's contents with rules, rebuilding both the ID
+// map and the host index from scratch. A rule whose ID() repeats an earlier
+// one's is skipped; NewRewriteRule gives every rule it builds a fresh
+// random UUID, so only the same rule value passed twice is dropped.
+func (s *RuleStore) Update(rules []C.Rewrite) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	byID := make(map[string]C.Rewrite, len(rules))
+	byKey := map[string][]C.Rewrite{}
+
+	for _, r := range rules {
+		if _, dup := byID[r.ID()]; dup {
+			continue
+		}
+		byID[r.ID()] = r
+		key := ruleKey(r)
+		byKey[key] = append(byKey[key], r)
+	}
+
+	s.byID = byID
+	s.byKey = byKey
+}
+
+// Lookup returns the rules that apply to host, plus any rule that couldn't
+// be indexed to a specific host.
+func (s *RuleStore) Lookup(host string) []C.Rewrite {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	rules := append([]C.Rewrite(nil), s.byKey[host]...)
+	if host != "*" {
+		rules = append(rules, s.byKey["*"]...)
+	}
+	return rules
+}
+
+// literalHost extracts a pattern's fixed host prefix, if it has one, e.g.
+// "^https?://example\\.com/" -> "example.com" (an escaped "\." is a literal
+// dot, so it's unescaped into the host rather than treated as a regex
+// metacharacter). Patterns that start with a wildcard or character class
+// aren't indexable and fall back to the "*" bucket.
+func literalHost(pattern string) string {
+	const schemeSep = "://"
+	i := strings.Index(pattern, schemeSep)
+	if i < 0 {
+		return ""
+	}
+	rest := pattern[i+len(schemeSep):]
+
+	var host []byte
+	for j := 0; j < len(rest); j++ {
+		c := rest[j]
+		if c == '\\' && j+1 < len(rest) {
+			if rest[j+1] != '.' {
+				return "" // not a plain literal host
+			}
+			host = append(host, '.')
+			j++
+			continue
+		}
+		if c == '/' {
+			break
+		}
+		if strings.ContainsRune(".^$()[]*+?|{}", rune(c)) {
+			// the host isn't a fixed literal; indexing the prefix read
+			// so far would hide the rule from Lookup on the real host
+			return ""
+		}
+		host = append(host, c)
+	}
+	return string(host)
+}
@@ -2,6 +2,8 @@ package rules
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/Dreamacro/clash/component/geodata"
 	"github.com/Dreamacro/clash/component/geodata/router"
@@ -11,9 +13,12 @@ import (
 
 type GEOSITE struct {
 	*Base
-	country string
-	adapter string
-	matcher *router.DomainMatcher
+	country      string
+	payload      string
+	includeAttrs []string
+	excludeAttrs []string
+	adapter      string
+	matcher      *router.DomainMatcher
 }
 
 func (gs *GEOSITE) RuleType() C.RuleType {
@@ -32,8 +37,11 @@ func (gs *GEOSITE) Adapter() string {
 	return gs.adapter
 }
 
+// Payload returns the rule's raw `country@attr1@!attr2` argument, so it
+// round-trips through whatever re-serializes the rule set (e.g. the
+// RESTful rules API) exactly as configured.
 func (gs *GEOSITE) Payload() string {
-	return gs.country
+	return gs.payload
 }
 
 func (gs *GEOSITE) ShouldResolveIP() bool {
@@ -44,8 +52,49 @@ func (gs *GEOSITE) GetDomainMatcher() *router.DomainMatcher {
 	return gs.matcher
 }
 
-func NewGEOSITE(country string, adapter string) (*GEOSITE, error) {
-	matcher, recordsCount, err := geodata.LoadProviderByCode(country)
+// parseGeoSitePayload splits a GEOSITE rule payload of the form
+// `country`, `country@attr1@attr2` or `country@!attr` into the bare
+// country code and its include/exclude attribute lists. An attribute
+// prefixed with `!` must be absent from a matched entry; every other
+// attribute must be present. Attrs are returned sorted so that two rules
+// naming the same attrs in a different order share the same loader cache
+// entry.
+func parseGeoSitePayload(payload string) (country string, includeAttrs, excludeAttrs []string, err error) {
+	parts := strings.Split(payload, "@")
+	country = strings.ToLower(strings.TrimSpace(parts[0]))
+	if country == "" {
+		return "", nil, nil, fmt.Errorf("empty GEOSITE country code in %q", payload)
+	}
+
+	for _, attr := range parts[1:] {
+		attr = strings.TrimSpace(attr)
+		exclude := strings.HasPrefix(attr, "!")
+		if exclude {
+			attr = attr[1:]
+		}
+		attr = strings.ToLower(attr)
+		if attr == "" {
+			return "", nil, nil, fmt.Errorf("empty GEOSITE attribute in %q", payload)
+		}
+		if exclude {
+			excludeAttrs = append(excludeAttrs, attr)
+		} else {
+			includeAttrs = append(includeAttrs, attr)
+		}
+	}
+
+	sort.Strings(includeAttrs)
+	sort.Strings(excludeAttrs)
+	return country, includeAttrs, excludeAttrs, nil
+}
+
+func NewGEOSITE(payload string, adapter string) (*GEOSITE, error) {
+	country, includeAttrs, excludeAttrs, err := parseGeoSitePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, recordsCount, err := geodata.LoadProviderByCode(country, includeAttrs, excludeAttrs)
 	if err != nil {
 		return nil, fmt.Errorf("load GeoSite data error, %s", err.Error())
 	}
@@ -57,13 +106,16 @@ func NewGEOSITE(country string, adapter string) (*GEOSITE, error) {
 	if adapter == C.ScriptRuleGeoSiteTarget {
 		adapter = "Script"
 	}
-	log.Infoln("Start initial GeoSite rule %s => %s, records: %s", country, adapter, count)
+	log.Infoln("Start initial GeoSite rule %s => %s, records: %s", payload, adapter, count)
 
 	geoSite := &GEOSITE{
-		Base:    &Base{},
-		country: country,
-		adapter: adapter,
-		matcher: matcher,
+		Base:         &Base{},
+		country:      country,
+		payload:      payload,
+		includeAttrs: includeAttrs,
+		excludeAttrs: excludeAttrs,
+		adapter:      adapter,
+		matcher:      matcher,
 	}
 
 	return geoSite, nil
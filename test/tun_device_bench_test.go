@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Dreamacro/clash/listener/tun/device"
+)
+
+// loopbackDevice is an in-process device.BatchDevice double used to
+// benchmark the batched ReadPackets/WritePackets path without a real kernel
+// TUN - this sandbox has neither the privileges nor the platform support to
+// open one, and the benchmark only cares about the batching overhead itself,
+// not the kernel's packet delivery.
+type loopbackDevice struct {
+	r, w net.Conn
+}
+
+func newLoopbackDevice() *loopbackDevice {
+	r, w := net.Pipe()
+	return &loopbackDevice{r: r, w: w}
+}
+
+func (l *loopbackDevice) Name() string                { return "loopback" }
+func (l *loopbackDevice) MTU() uint32                 { return 1500 }
+func (l *loopbackDevice) Close() error                { _ = l.r.Close(); return l.w.Close() }
+func (l *loopbackDevice) UseEndpoint() error          { return nil }
+func (l *loopbackDevice) UseIOBased() error           { return nil }
+func (l *loopbackDevice) Read(p []byte) (int, error)  { return l.r.Read(p) }
+func (l *loopbackDevice) Write(p []byte) (int, error) { return l.w.Write(p) }
+
+func (l *loopbackDevice) ReadPackets(bufs [][]byte, sizes []int) (int, error) {
+	for i := range bufs {
+		n, err := l.r.Read(bufs[i])
+		if err != nil {
+			return i, err
+		}
+		sizes[i] = n
+	}
+	return len(bufs), nil
+}
+
+func (l *loopbackDevice) WritePackets(bufs [][]byte) (int, error) {
+	for i, buf := range bufs {
+		if _, err := l.w.Write(buf); err != nil {
+			return i, err
+		}
+	}
+	return len(bufs), nil
+}
+
+var _ device.BatchDevice = (*loopbackDevice)(nil)
+
+const benchPacketSize = 1420
+
+func BenchmarkTUNReadWrite(b *testing.B) {
+	dev := newLoopbackDevice()
+	defer dev.Close()
+
+	packet := make([]byte, benchPacketSize)
+	read := make([]byte, benchPacketSize)
+
+	go func() {
+		for i := 0; i < b.N; i++ {
+			_, _ = dev.Write(packet)
+		}
+	}()
+
+	b.SetBytes(benchPacketSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = dev.Read(read)
+	}
+}
+
+func BenchmarkTUNBatchReadWrite(b *testing.B) {
+	dev := newLoopbackDevice()
+	defer dev.Close()
+
+	const batch = 128
+	writeBufs := make([][]byte, batch)
+	readBufs := make([][]byte, batch)
+	sizes := make([]int, batch)
+	for i := range writeBufs {
+		writeBufs[i] = make([]byte, benchPacketSize)
+		readBufs[i] = make([]byte, benchPacketSize)
+	}
+
+	rounds := b.N/batch + 1
+	go func() {
+		for i := 0; i < rounds; i++ {
+			_, _ = dev.WritePackets(writeBufs)
+		}
+	}()
+
+	b.SetBytes(benchPacketSize)
+	b.ResetTimer()
+	done := 0
+	for done < b.N {
+		n, _ := dev.ReadPackets(readBufs, sizes)
+		done += n
+	}
+}
@@ -0,0 +1,48 @@
+package gun
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	tlsC "github.com/Dreamacro/clash/component/tls"
+	"golang.org/x/net/http2"
+)
+
+// NewHTTP2RealityClient mirrors NewHTTP2XTLSClient, but upgrades the raw
+// connection with tlsC.DialRealityConn instead of a plain or XTLS
+// handshake, as the non-gun transports do via streamTLSOrXTLSConn.
+// DialRealityConn always returns errNeedsUTLS, so every dial through this
+// transport fails.
+func NewHTTP2RealityClient(dialFn DialFn, reality *tlsC.RealityConfig, sni string, pin *[32]byte) *http2.Transport {
+	dialFunc := func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+		pconn, err := dialFn(network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		serverName := sni
+		if cfg != nil && cfg.ServerName != "" {
+			serverName = cfg.ServerName
+		}
+
+		return tlsC.DialRealityConn(pconn, serverName, reality, pin)
+	}
+
+	return &http2.Transport{
+		DialTLSContext:     dialFunc,
+		AllowHTTP:          false,
+		DisableCompression: true,
+		PingTimeout:        0,
+	}
+}
+
+// StreamGunWithRealityConn is StreamGunWithXTLSConn's REALITY counterpart.
+func StreamGunWithRealityConn(conn net.Conn, reality *tlsC.RealityConfig, sni string, pin *[32]byte, cfg *Config) (net.Conn, error) {
+	dialFn := func(network, addr string) (net.Conn, error) {
+		return conn, nil
+	}
+
+	transport := NewHTTP2RealityClient(dialFn, reality, sni, pin)
+	return StreamGunWithTransport(transport, cfg)
+}
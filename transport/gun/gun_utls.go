@@ -0,0 +1,50 @@
+package gun
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	tlsC "github.com/Dreamacro/clash/component/tls"
+	"golang.org/x/net/http2"
+)
+
+// NewHTTP2UTLSClient mirrors NewHTTP2RealityClient, but upgrades the raw
+// connection with tlsC.DialWithFingerprint instead of a plain TLS
+// handshake. DialWithFingerprint has no uTLS client to present the
+// fingerprint with and always returns an error, so every dial through
+// this transport fails.
+func NewHTTP2UTLSClient(dialFn DialFn, fingerprint string, tlsConfig *tls.Config) *http2.Transport {
+	dialFunc := func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+		pconn, err := dialFn(network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		cfgCopy := cfg
+		if cfgCopy == nil {
+			cfgCopy = tlsConfig
+		}
+
+		return tlsC.DialWithFingerprint(ctx, pconn, fingerprint, cfgCopy)
+	}
+
+	return &http2.Transport{
+		DialTLSContext:     dialFunc,
+		AllowHTTP:          false,
+		DisableCompression: true,
+		PingTimeout:        0,
+	}
+}
+
+// StreamGunWithUTLSConn is StreamGunWithRealityConn's plain-fingerprint
+// counterpart, used when a grpc/gun outbound sets client-fingerprint but
+// isn't a REALITY server.
+func StreamGunWithUTLSConn(conn net.Conn, fingerprint string, tlsConfig *tls.Config, cfg *Config) (net.Conn, error) {
+	dialFn := func(network, addr string) (net.Conn, error) {
+		return conn, nil
+	}
+
+	transport := NewHTTP2UTLSClient(dialFn, fingerprint, tlsConfig)
+	return StreamGunWithTransport(transport, cfg)
+}
@@ -0,0 +1,86 @@
+package ss
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/Dreamacro/clash/transport/socks5"
+)
+
+// ReadAddr reads a Shadowsocks request target address off r. Shadowsocks
+// reuses the exact SOCKS5 address encoding (atyp + address + big-endian
+// port), just without the rest of the SOCKS5 request/reply handshake
+// around it.
+func ReadAddr(r io.Reader) (socks5.Addr, error) {
+	var atyp [1]byte
+	if _, err := io.ReadFull(r, atyp[:]); err != nil {
+		return nil, err
+	}
+
+	switch atyp[0] {
+	case socks5.AtypIPv4:
+		buf := make([]byte, 1+net.IPv4len+2)
+		buf[0] = atyp[0]
+		if _, err := io.ReadFull(r, buf[1:]); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	case socks5.AtypIPv6:
+		buf := make([]byte, 1+net.IPv6len+2)
+		buf[0] = atyp[0]
+		if _, err := io.ReadFull(r, buf[1:]); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	case socks5.AtypDomainName:
+		var length [1]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 1+1+int(length[0])+2)
+		buf[0] = atyp[0]
+		buf[1] = length[0]
+		if _, err := io.ReadFull(r, buf[2:]); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("ss: unsupported address type: %#x", atyp[0])
+	}
+}
+
+// WriteAddr encodes addr back into the SOCKS5-style wire format ReadAddr
+// expects, for a UDP relay echoing a target back to a client.
+func WriteAddr(addr socks5.Addr, port uint16) []byte {
+	buf := make([]byte, len(addr))
+	copy(buf, addr)
+	binary.BigEndian.PutUint16(buf[len(buf)-2:], port)
+	return buf
+}
+
+// SplitAddr reads a target address off the front of a UDP datagram's
+// plaintext payload, returning the address and the remaining payload.
+func SplitAddr(payload []byte) (addr socks5.Addr, rest []byte, err error) {
+	r := &byteReader{b: payload}
+	addr, err = ReadAddr(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return addr, payload[r.pos:], nil
+}
+
+type byteReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *byteReader) Read(p []byte) (int, error) {
+	if r.pos >= len(r.b) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.b[r.pos:])
+	r.pos += n
+	return n, nil
+}
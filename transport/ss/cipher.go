@@ -0,0 +1,109 @@
+// Package ss implements the Shadowsocks AEAD wire protocol (SIP004): a
+// per-connection subkey derived from the cipher's pre-shared key and a
+// random salt via HKDF-SHA1, then length-prefixed chunks sealed with that
+// subkey, each chunk's nonce incrementing by one.
+package ss
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const maxChunkSize = 0xFFFF
+
+// hkdfInfo is the fixed SIP004 HKDF info string.
+var hkdfInfo = []byte("ss-subkey")
+
+// cipherSpec describes one of the ciphers SupportedCiphers lists.
+type cipherSpec struct {
+	keySize  int
+	saltSize int
+	newAEAD  func(key []byte) (cipher.AEAD, error)
+}
+
+var ciphers = map[string]cipherSpec{
+	"aes-128-gcm": {keySize: 16, saltSize: 16, newAEAD: aesGCM},
+	"aes-192-gcm": {keySize: 24, saltSize: 24, newAEAD: aesGCM},
+	"aes-256-gcm": {keySize: 32, saltSize: 32, newAEAD: aesGCM},
+	"chacha20-ietf-poly1305": {keySize: 32, saltSize: 32, newAEAD: func(key []byte) (cipher.AEAD, error) {
+		return chacha20poly1305.New(key)
+	}},
+}
+
+func aesGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SupportedCiphers lists the cipher names NewCipher accepts.
+func SupportedCiphers() []string {
+	names := make([]string, 0, len(ciphers))
+	for name := range ciphers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Cipher derives per-connection AEADs from a pre-shared key, for StreamConn
+// and EncryptPacket/DecryptPacket to seal and open chunks and datagrams with.
+type Cipher struct {
+	spec cipherSpec
+	key  []byte
+}
+
+// NewCipher validates name and derives the master key from password with
+// the same EVP_BytesToKey-style scheme Shadowsocks has always used.
+func NewCipher(name, password string) (*Cipher, error) {
+	spec, ok := ciphers[name]
+	if !ok {
+		return nil, fmt.Errorf("ss: unsupported cipher: %s", name)
+	}
+	return &Cipher{spec: spec, key: kdf(password, spec.keySize)}, nil
+}
+
+// SaltSize is the size of the random salt that prefixes a StreamConn's
+// byte stream and every datagram EncryptPacket seals.
+func (c *Cipher) SaltSize() int {
+	return c.spec.saltSize
+}
+
+func (c *Cipher) subkeyAEAD(salt []byte) (cipher.AEAD, error) {
+	subkey := make([]byte, c.spec.keySize)
+	if _, err := hkdf.New(sha1.New, c.key, salt, hkdfInfo).Read(subkey); err != nil {
+		return nil, err
+	}
+	return c.spec.newAEAD(subkey)
+}
+
+// NewSalt returns a fresh random salt of this cipher's SaltSize.
+func (c *Cipher) NewSalt() ([]byte, error) {
+	salt := make([]byte, c.spec.saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// kdf is OpenSSL's EVP_BytesToKey with MD5, which Shadowsocks has always
+// used to turn an arbitrary-length password into a fixed-size master key.
+func kdf(password string, keyLen int) []byte {
+	var b, prev []byte
+	for len(b) < keyLen {
+		h := md5.New()
+		h.Write(prev)
+		h.Write([]byte(password))
+		prev = h.Sum(nil)
+		b = append(b, prev...)
+	}
+	return b[:keyLen]
+}
@@ -0,0 +1,39 @@
+package ss
+
+import "fmt"
+
+// DecryptPacket opens a single Shadowsocks UDP datagram: salt prefix
+// followed by one AEAD-sealed payload, the nonce always zero since each
+// datagram carries its own fresh salt/subkey.
+func DecryptPacket(c *Cipher, packet []byte) ([]byte, error) {
+	if len(packet) < c.SaltSize() {
+		return nil, fmt.Errorf("ss: packet shorter than salt")
+	}
+
+	salt := packet[:c.SaltSize()]
+	aead, err := c.subkeyAEAD(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	return aead.Open(nil, nonce, packet[c.SaltSize():], nil)
+}
+
+// EncryptPacket is DecryptPacket's inverse, generating a fresh salt per
+// datagram as SIP004 requires.
+func EncryptPacket(c *Cipher, payload []byte) ([]byte, error) {
+	salt, err := c.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := c.subkeyAEAD(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	sealed := aead.Seal(nil, nonce, payload, nil)
+	return append(salt, sealed...), nil
+}
@@ -0,0 +1,147 @@
+package ss
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// StreamConn wraps a net.Conn with SIP004 AEAD chunk framing: the first
+// bytes read/written on the connection are the random salt, every
+// subsequent chunk is a 2-byte sealed length followed by the sealed
+// payload, with the nonce incrementing after every seal/open.
+type StreamConn struct {
+	net.Conn
+	cipher *Cipher
+
+	readAEAD  cipher.AEAD
+	readNonce []byte
+	readBuf   []byte
+
+	writeAEAD  cipher.AEAD
+	writeNonce []byte
+}
+
+func NewStreamConn(conn net.Conn, c *Cipher) *StreamConn {
+	return &StreamConn{Conn: conn, cipher: c}
+}
+
+func (c *StreamConn) ensureWriteAEAD() error {
+	if c.writeAEAD != nil {
+		return nil
+	}
+	salt, err := c.cipher.NewSalt()
+	if err != nil {
+		return err
+	}
+	if _, err := c.Conn.Write(salt); err != nil {
+		return err
+	}
+	aead, err := c.cipher.subkeyAEAD(salt)
+	if err != nil {
+		return err
+	}
+	c.writeAEAD = aead
+	c.writeNonce = make([]byte, aead.NonceSize())
+	return nil
+}
+
+func (c *StreamConn) ensureReadAEAD() error {
+	if c.readAEAD != nil {
+		return nil
+	}
+	salt := make([]byte, c.cipher.SaltSize())
+	if _, err := io.ReadFull(c.Conn, salt); err != nil {
+		return err
+	}
+	aead, err := c.cipher.subkeyAEAD(salt)
+	if err != nil {
+		return err
+	}
+	c.readAEAD = aead
+	c.readNonce = make([]byte, aead.NonceSize())
+	return nil
+}
+
+func incNonce(nonce []byte) {
+	for i := range nonce {
+		nonce[i]++
+		if nonce[i] != 0 {
+			return
+		}
+	}
+}
+
+// Write seals b as one or more length-prefixed chunks.
+func (c *StreamConn) Write(b []byte) (int, error) {
+	if err := c.ensureWriteAEAD(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > maxChunkSize {
+			chunk = chunk[:maxChunkSize]
+		}
+
+		var lenBuf [2]byte
+		binary.BigEndian.PutUint16(lenBuf[:], uint16(len(chunk)))
+		sealedLen := c.writeAEAD.Seal(nil, c.writeNonce, lenBuf[:], nil)
+		incNonce(c.writeNonce)
+
+		sealedChunk := c.writeAEAD.Seal(nil, c.writeNonce, chunk, nil)
+		incNonce(c.writeNonce)
+
+		if _, err := c.Conn.Write(append(sealedLen, sealedChunk...)); err != nil {
+			return total, err
+		}
+
+		total += len(chunk)
+		b = b[len(chunk):]
+	}
+	return total, nil
+}
+
+// Read returns the next chunk's plaintext, buffering any of it that
+// doesn't fit in b until the following call.
+func (c *StreamConn) Read(b []byte) (int, error) {
+	if len(c.readBuf) > 0 {
+		n := copy(b, c.readBuf)
+		c.readBuf = c.readBuf[n:]
+		return n, nil
+	}
+
+	if err := c.ensureReadAEAD(); err != nil {
+		return 0, err
+	}
+
+	sealedLen := make([]byte, 2+c.readAEAD.Overhead())
+	if _, err := io.ReadFull(c.Conn, sealedLen); err != nil {
+		return 0, err
+	}
+	lenBuf, err := c.readAEAD.Open(nil, c.readNonce, sealedLen, nil)
+	if err != nil {
+		return 0, fmt.Errorf("ss: open length: %w", err)
+	}
+	incNonce(c.readNonce)
+
+	chunkLen := binary.BigEndian.Uint16(lenBuf)
+	sealedChunk := make([]byte, int(chunkLen)+c.readAEAD.Overhead())
+	if _, err := io.ReadFull(c.Conn, sealedChunk); err != nil {
+		return 0, err
+	}
+	chunk, err := c.readAEAD.Open(nil, c.readNonce, sealedChunk, nil)
+	if err != nil {
+		return 0, fmt.Errorf("ss: open chunk: %w", err)
+	}
+	incNonce(c.readNonce)
+
+	n := copy(b, chunk)
+	if n < len(chunk) {
+		c.readBuf = chunk[n:]
+	}
+	return n, nil
+}
@@ -2,12 +2,12 @@ package statistic
 
 import (
 	"errors"
-	"strings"
+	"net"
 
 	"go.uber.org/atomic"
 
-	"github.com/Dreamacro/clash/common/snifer/tls"
 	"github.com/Dreamacro/clash/component/resolver"
+	"github.com/Dreamacro/clash/component/sniffer"
 	C "github.com/Dreamacro/clash/constant"
 	"github.com/Dreamacro/clash/log"
 )
@@ -16,6 +16,7 @@ type sniffing struct {
 	C.Conn
 
 	metadata   *C.Metadata
+	sniffers   *sniffer.Set
 	totalWrite *atomic.Uint64
 	allowBreak bool
 }
@@ -25,18 +26,16 @@ func (r *sniffing) Read(b []byte) (int, error) {
 }
 
 func (r *sniffing) Write(b []byte) (int, error) {
-	if r.totalWrite.Load() < 128 && r.metadata.Host == "" &&
-		(r.metadata.DstPort == "443" || r.metadata.DstPort == "8443" || r.metadata.DstPort == "993" ||
-			r.metadata.DstPort == "465" || r.metadata.DstPort == "995") {
-		header, err := tls.SniffTLS(b)
-		if err == nil && strings.Index(header.Domain(), ".") > 0 {
-			resolver.InsertHostByIP(r.metadata.DstIP, header.Domain())
-			log.Debugln("[Sniffer] use sni update host: %s ip: %s", header.Domain(), r.metadata.DstIP.String())
+	port, _ := parsePort(r.metadata.DstPort)
+	if r.totalWrite.Load() < 128 && r.metadata.Host == "" && r.sniffers.SupportsPort(uint16(port)) {
+		if host, protocol, err := r.sniffers.Sniff(b); err == nil {
+			resolver.InsertHostByIP(r.metadata.DstIP, host)
+			log.Debugln("[Sniffer] use %s sni update host: %s ip: %s", protocol, host, r.metadata.DstIP.String())
 			if r.allowBreak {
 				_ = r.Conn.Close()
 				return 0, errors.New("sni update, break current link to avoid leaks")
 			} else {
-				r.metadata.Host = header.Domain()
+				r.metadata.Host = host
 			}
 		}
 	}
@@ -51,10 +50,60 @@ func (r *sniffing) Close() error {
 	return r.Conn.Close()
 }
 
-func NewSniffing(conn C.Conn, metadata *C.Metadata, rule C.Rule) C.Conn {
+func parsePort(s string) (int, error) {
+	n := 0
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, errors.New("invalid port")
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, nil
+}
+
+type sniffingPacketConn struct {
+	C.PacketConn
+
+	metadata *C.Metadata
+	sniffers *sniffer.Set
+	done     atomic.Bool
+}
+
+func (pc *sniffingPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	if !pc.done.Load() && pc.metadata.Host == "" && pc.sniffers.SupportsPort(uint16(dstPort(pc.metadata))) {
+		if host, protocol, err := pc.sniffers.Sniff(b); err == nil {
+			pc.done.Store(true)
+			resolver.InsertHostByIP(pc.metadata.DstIP, host)
+			log.Debugln("[Sniffer] use %s sni update host: %s ip: %s", protocol, host, pc.metadata.DstIP.String())
+			pc.metadata.Host = host
+		}
+	}
+
+	return pc.PacketConn.WriteTo(b, addr)
+}
+
+func dstPort(metadata *C.Metadata) int {
+	port, _ := parsePort(metadata.DstPort)
+	return port
+}
+
+// NewSniffingPacketConn wraps a UDP C.PacketConn so that, until the real
+// destination Host is known, every outgoing datagram is run through the
+// sniffer set (whose QUIC sniffer recovers the SNI carried in the
+// ClientHello), mirroring NewSniffing's TCP behaviour. Unlike NewSniffing
+// there is no byte limit: sniffing stops only once one datagram yields a
+// host.
+func NewSniffingPacketConn(pc C.PacketConn, metadata *C.Metadata, sniffers *sniffer.Set) C.PacketConn {
+	return &sniffingPacketConn{PacketConn: pc, metadata: metadata, sniffers: sniffers}
+}
+
+// NewSniffing wraps conn so that, until the real destination Host is known,
+// outgoing bytes are inspected by the given sniffer set to recover it.
+func NewSniffing(conn C.Conn, metadata *C.Metadata, rule C.Rule, sniffers *sniffer.Set) C.Conn {
 	return &sniffing{
 		Conn:       conn,
 		metadata:   metadata,
+		sniffers:   sniffers,
 		totalWrite: atomic.NewUint64(0),
 		allowBreak: rule != nil,
 	}
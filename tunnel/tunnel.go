@@ -13,9 +13,11 @@ import (
 
 	A "github.com/Dreamacro/clash/adapter"
 	"github.com/Dreamacro/clash/adapter/inbound"
+	"github.com/Dreamacro/clash/common/batch"
 	"github.com/Dreamacro/clash/component/nat"
 	P "github.com/Dreamacro/clash/component/process"
 	"github.com/Dreamacro/clash/component/resolver"
+	"github.com/Dreamacro/clash/component/sniffer"
 	"github.com/Dreamacro/clash/component/trie"
 	C "github.com/Dreamacro/clash/constant"
 	"github.com/Dreamacro/clash/constant/provider"
@@ -33,13 +35,14 @@ var (
 	providers    map[string]provider.ProxyProvider
 	rewrites     C.RewriteRule
 	rewriteHosts *trie.DomainTrie[bool]
+	subRules     map[string][]C.Rule
 	configMux    sync.RWMutex
 
 	// Outbound Rule
 	mode = Rule
 
-	// sniffing switch
-	sniffing = false
+	// snifferSet is the active sniffer set, nil when sniffing is disabled
+	snifferSet *sniffer.Set
 
 	// default timeout for UDP session
 	udpTimeout = 60 * time.Second
@@ -78,6 +81,35 @@ func Rules() []C.Rule {
 	return rules
 }
 
+const defaultHealthCheckConcurrency = 10
+
+// HealthCheckAll probes every configured proxy against url under a single
+// bounded worker pool and shared context deadline, for an on-demand,
+// whole-config health check (e.g. triggered from the API) rather than one
+// provider's own periodic HealthCheck rotation. concurrency <= 0 falls
+// back to defaultHealthCheckConcurrency.
+func HealthCheckAll(ctx context.Context, url string, concurrency int) (map[string]uint16, error) {
+	if concurrency <= 0 {
+		concurrency = defaultHealthCheckConcurrency
+	}
+
+	configMux.RLock()
+	snapshot := make(map[string]C.Proxy, len(proxies))
+	for name, proxy := range proxies {
+		snapshot[name] = proxy
+	}
+	configMux.RUnlock()
+
+	b, bCtx := batch.New[uint16](ctx, batch.WithConcurrencyNum[uint16](concurrency))
+	for name, proxy := range snapshot {
+		name, proxy := name, proxy
+		b.Go(name, func() (uint16, error) {
+			return proxy.URLTest(bCtx, url)
+		})
+	}
+	return b.Wait()
+}
+
 // UpdateRules handle update rules
 func UpdateRules(newRules []C.Rule) {
 	configMux.Lock()
@@ -85,6 +117,19 @@ func UpdateRules(newRules []C.Rule) {
 	configMux.Unlock()
 }
 
+// SubRules return the named sub-rule chains, keyed by `sub-rules:` name
+func SubRules() map[string][]C.Rule {
+	return subRules
+}
+
+// UpdateSubRules handle update of the named sub-rule chains used by
+// Metadata.PreferRulesName to scope matching to a single inbound
+func UpdateSubRules(newSubRules map[string][]C.Rule) {
+	configMux.Lock()
+	subRules = newSubRules
+	configMux.Unlock()
+}
+
 // Proxies return all proxies
 func Proxies() map[string]C.Proxy {
 	return proxies
@@ -115,11 +160,13 @@ func SetMode(m TunnelMode) {
 }
 
 func Sniffing() bool {
-	return sniffing
+	return snifferSet != nil
 }
 
-func SetSniffing(s bool) {
-	sniffing = s
+// SetSniffer installs the sniffer set used to recover the real destination
+// domain from TLS/HTTP/QUIC handshakes, or disables sniffing when set is nil.
+func SetSniffer(set *sniffer.Set) {
+	snifferSet = set
 }
 
 // SetMitmOutbound set the MITM outbound
@@ -195,9 +242,9 @@ func preHandleMetadata(metadata *C.Metadata) error {
 			if resolver.FakeIPEnabled() {
 				metadata.DstIP = netip.Addr{}
 				metadata.DNSMode = C.DNSFakeIP
-			} else if node := resolver.DefaultHosts.Search(host); node != nil {
+			} else if ip, ok := resolver.LookupIPByHost(host); ok {
 				// redir-host should lookup the hosts
-				metadata.DstIP = node.Data
+				metadata.DstIP = ip
 			}
 		} else if resolver.IsFakeIP(metadata.DstIP) {
 			return fmt.Errorf("fake DNS record %s missing", metadata.DstIP)
@@ -207,6 +254,18 @@ func preHandleMetadata(metadata *C.Metadata) error {
 	return nil
 }
 
+// udpDomainCapable is implemented by outbounds whose UDP framing carries
+// the destination domain on the wire (e.g. VLESS XUDP), so handleUDPConn
+// doesn't need to resolve it locally before dialing.
+type udpDomainCapable interface {
+	SupportsUDPDomain() bool
+}
+
+func supportsUDPDomain(proxy C.Proxy) bool {
+	aware, ok := proxy.(udpDomainCapable)
+	return ok && aware.SupportsUDPDomain()
+}
+
 func resolveMetadata(_ C.PlainContext, metadata *C.Metadata) (proxy C.Proxy, rule C.Rule, err error) {
 	if metadata.NetWork == C.TCP && mitmProxy != nil && metadata.Type != C.MITM &&
 		((rewriteHosts != nil && rewriteHosts.Search(metadata.String()) != nil) || metadata.DstPort == "80") {
@@ -246,15 +305,6 @@ func handleUDPConn(packet *inbound.PacketAdapter) {
 		return
 	}
 
-	// local resolve UDP dns
-	if !metadata.Resolved() {
-		ip, err := resolver.ResolveFirstIP(metadata.Host)
-		if err != nil {
-			return
-		}
-		metadata.DstIP = ip
-	}
-
 	key := packet.LocalAddr().String()
 
 	handle := func() bool {
@@ -294,6 +344,21 @@ func handleUDPConn(packet *inbound.PacketAdapter) {
 			return
 		}
 
+		// local resolve UDP dns, unless the chosen proxy can carry the
+		// destination domain itself (e.g. VLESS XUDP) and doesn't need an
+		// IP up front. Note this only skips our own lookup - the relay
+		// dispatch that actually calls pc.WriteTo still builds its target
+		// net.Addr from metadata, so the domain only reaches the proxy
+		// once that dispatch is domain-address-aware too.
+		if !metadata.Resolved() && !supportsUDPDomain(proxy) {
+			ip, err := resolver.ResolveFirstIP(metadata.Host)
+			if err != nil {
+				log.Warnln("[UDP] resolve %s: %s", metadata.Host, err.Error())
+				return
+			}
+			metadata.DstIP = ip
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), C.DefaultUDPTimeout)
 		defer cancel()
 		rawPc, err := proxy.ListenPacketContext(ctx, metadata.Pure(false))
@@ -306,7 +371,10 @@ func handleUDPConn(packet *inbound.PacketAdapter) {
 			return
 		}
 		pCtx.InjectPacketConn(rawPc)
-		pc := statistic.NewUDPTracker(rawPc, statistic.DefaultManager, metadata, rule)
+		var pc C.PacketConn = statistic.NewUDPTracker(rawPc, statistic.DefaultManager, metadata, rule)
+		if snifferSet != nil {
+			pc = statistic.NewSniffingPacketConn(pc, metadata, snifferSet)
+		}
 
 		switch true {
 		case rule != nil:
@@ -329,6 +397,65 @@ func handleUDPConn(packet *inbound.PacketAdapter) {
 	}()
 }
 
+// preDialSniffTimeout bounds how long handleTCPConn waits for the client's
+// first flight before giving up on pre-dial sniffing and proceeding with
+// whatever destination metadata already carries.
+const preDialSniffTimeout = 200 * time.Millisecond
+
+// preDialSniff peeks the first bytes off connCtx's conn, before any proxy is
+// dialled, so rule matching can see a sniffed TLS SNI/HTTP Host even when
+// the client only gave us a bare IP. Unlike statistic.NewSniffing - which
+// only updates metadata.Host for DNS/observability purposes after a proxy
+// has already been dialled - this can change which proxy/rule
+// resolveMetadata picks. It returns a ConnContext wrapping whatever bytes
+// were peeked so they still reach the eventual remote connection.
+func preDialSniff(connCtx C.ConnContext, metadata *C.Metadata) C.ConnContext {
+	if snifferSet == nil || metadata.NetWork != C.TCP {
+		return connCtx
+	}
+
+	port, err := strconv.ParseUint(metadata.DstPort, 10, 16)
+	if err != nil || !snifferSet.SupportsPort(uint16(port)) || !snifferSet.ShouldSniff(metadata.Host) {
+		return connCtx
+	}
+
+	conn := connCtx.Conn()
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(preDialSniffTimeout))
+	n, err := conn.Read(buf)
+	_ = conn.SetReadDeadline(time.Time{})
+	if err != nil || n == 0 {
+		return connCtx
+	}
+	buf = buf[:n]
+
+	if host, protocol, err := snifferSet.Sniff(buf); err == nil && host != "" &&
+		(metadata.Host == "" || snifferSet.OverrideDestination()) {
+		log.Debugln("[Sniffer] pre-dial %s sni: %s", protocol, host)
+		resolver.InsertHostByIP(metadata.DstIP, host)
+		metadata.Host = host
+	}
+
+	return icontext.NewConnContext(&peekedConn{Conn: conn, buf: buf}, metadata)
+}
+
+// peekedConn replays buf before resuming reads from the wrapped net.Conn, so
+// bytes consumed while pre-dial sniffing aren't lost to whatever the
+// connection ends up being relayed to.
+type peekedConn struct {
+	net.Conn
+	buf []byte
+}
+
+func (c *peekedConn) Read(b []byte) (int, error) {
+	if len(c.buf) > 0 {
+		n := copy(b, c.buf)
+		c.buf = c.buf[n:]
+		return n, nil
+	}
+	return c.Conn.Read(b)
+}
+
 func handleTCPConn(connCtx C.ConnContext) {
 	defer func(conn net.Conn) {
 		_ = conn.Close()
@@ -345,6 +472,8 @@ func handleTCPConn(connCtx C.ConnContext) {
 		return
 	}
 
+	connCtx = preDialSniff(connCtx, metadata)
+
 	proxy, rule, err := resolveMetadata(connCtx, metadata)
 	if err != nil {
 		log.Warnln("[Metadata] parse failed: %s", err.Error())
@@ -367,8 +496,8 @@ func handleTCPConn(connCtx C.ConnContext) {
 
 	if remoteConn.Chains().Last() != "REJECT" && !isMitmOutbound {
 		remoteConn = statistic.NewTCPTracker(remoteConn, statistic.DefaultManager, metadata, rule)
-		if sniffing {
-			remoteConn = statistic.NewSniffing(remoteConn, metadata, rule)
+		if snifferSet != nil {
+			remoteConn = statistic.NewSniffing(remoteConn, metadata, rule, snifferSet)
 		}
 	}
 
@@ -398,6 +527,33 @@ func shouldResolveIP(rule C.Rule, metadata *C.Metadata) bool {
 	return rule.ShouldResolveIP() && metadata.Host != "" && !metadata.DstIP.IsValid()
 }
 
+var (
+	ruleMatchMux   sync.Mutex
+	ruleMatchCount = make(map[[2]string]uint64)
+)
+
+// RuleMatchSnapshot returns a copy of the rule-match counters accumulated
+// since start-up, keyed by (rule type, matched adapter), for consumers such
+// as the Prometheus exporter's clash_rule_match_total.
+func RuleMatchSnapshot() map[[2]string]uint64 {
+	ruleMatchMux.Lock()
+	defer ruleMatchMux.Unlock()
+
+	snapshot := make(map[[2]string]uint64, len(ruleMatchCount))
+	for k, v := range ruleMatchCount {
+		snapshot[k] = v
+	}
+	return snapshot
+}
+
+func recordRuleMatch(rule C.Rule) {
+	key := [2]string{rule.RuleType().String(), rule.Adapter()}
+
+	ruleMatchMux.Lock()
+	ruleMatchCount[key]++
+	ruleMatchMux.Unlock()
+}
+
 func match(metadata *C.Metadata) (C.Proxy, C.Rule, error) {
 	configMux.RLock()
 	defer configMux.RUnlock()
@@ -407,13 +563,36 @@ func match(metadata *C.Metadata) (C.Proxy, C.Rule, error) {
 		processFound bool
 	)
 
-	if node := resolver.DefaultHosts.Search(metadata.Host); node != nil {
-		metadata.DstIP = node.Data
+	if ip, ok := resolver.LookupIPByHost(metadata.Host); ok {
+		metadata.DstIP = ip
 		resolved = true
 	}
 
-	for _, rule := range rules {
-		if !resolved && shouldResolveIP(rule, metadata) {
+	// a named inbound with a preferred rule-group is matched against that
+	// sub-chain first, falling back to the global rules below on a miss
+	if metadata.PreferRulesName != "" {
+		if chain, ok := subRules[metadata.PreferRulesName]; ok {
+			if proxy, rule, matched := matchRules(chain, metadata, &resolved, &processFound); matched {
+				return proxy, rule, nil
+			}
+		}
+	}
+
+	return matchRulesOrReject(metadata, &resolved, &processFound)
+}
+
+func matchRulesOrReject(metadata *C.Metadata, resolved, processFound *bool) (C.Proxy, C.Rule, error) {
+	if proxy, rule, matched := matchRules(rules, metadata, resolved, processFound); matched {
+		return proxy, rule, nil
+	}
+	return proxies["REJECT"], nil, nil
+}
+
+// matchRules walks chain, resolving the metadata's IP/process on demand
+// exactly like the top-level rules loop, and returns the first match.
+func matchRules(chain []C.Rule, metadata *C.Metadata, resolved, processFound *bool) (C.Proxy, C.Rule, bool) {
+	for _, rule := range chain {
+		if !*resolved && shouldResolveIP(rule, metadata) {
 			ip, err := resolver.ResolveIP(metadata.Host)
 			if err != nil {
 				log.Debugln("[DNS] resolve %s error: %s", metadata.Host, err.Error())
@@ -421,11 +600,11 @@ func match(metadata *C.Metadata) (C.Proxy, C.Rule, error) {
 				log.Debugln("[DNS] %s --> %s", metadata.Host, ip.String())
 				metadata.DstIP = ip
 			}
-			resolved = true
+			*resolved = true
 		}
 
-		if !processFound && rule.ShouldFindProcess() {
-			processFound = true
+		if !*processFound && rule.ShouldFindProcess() {
+			*processFound = true
 
 			srcPort, err := strconv.ParseUint(metadata.SrcPort, 10, 16)
 			if err == nil {
@@ -451,19 +630,20 @@ func match(metadata *C.Metadata) (C.Proxy, C.Rule, error) {
 				continue
 			}
 
-			return adapter, rule, nil
+			recordRuleMatch(rule)
+			return adapter, rule, true
 		}
 	}
 
-	return proxies["REJECT"], nil, nil
+	return nil, nil, false
 }
 
 func matchScript(metadata *C.Metadata) (C.Proxy, error) {
 	configMux.RLock()
 	defer configMux.RUnlock()
 
-	if node := resolver.DefaultHosts.Search(metadata.Host); node != nil {
-		metadata.DstIP = node.Data
+	if ip, ok := resolver.LookupIPByHost(metadata.Host); ok {
+		metadata.DstIP = ip
 	}
 
 	adapter, err := scriptMainMatcher.Eval(metadata)